@@ -0,0 +1,170 @@
+package forge
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Sentinel errors matched by an APIError with errors.Is, e.g. errors.Is(err, forge.ErrNotFound)
+var (
+	ErrBadRequest    = errors.New("forge: bad request")
+	ErrUnauthorized  = errors.New("forge: unauthorized")
+	ErrForbidden     = errors.New("forge: forbidden")
+	ErrNotFound      = errors.New("forge: not found")
+	ErrConflict      = errors.New("forge: conflict")
+	ErrQuotaExceeded = errors.New("forge: quota exceeded")
+)
+
+// maxErrorBody limits how much of an error response is kept
+const maxErrorBody = 64 << 10
+
+// APIError is returned by the dm, md and recap packages when a Forge service rejects a request.
+// It gathers the error formats of the different services: OSS reasons, Data Management JSON:API errors,
+// Model Derivative diagnostics and the Reality Capture errors sent in successful responses.
+type APIError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Code is the Forge error code, e.g. ERR_NOT_FOUND for Data Management or the numeric code of Reality Capture
+	Code string
+	// Message is the human readable reason of the error
+	Message string
+	// Body is the raw body of the response, truncated to 64KB
+	Body []byte
+	// RequestID and TroubleshootingID are the identifiers to give to the Forge support
+	RequestID         string
+	TroubleshootingID string
+}
+
+// errorBody holds the fields the Forge services use to describe an error
+type errorBody struct {
+	Reason           string `json:"reason"`
+	DeveloperMessage string `json:"developerMessage"`
+	ErrorCode        string `json:"errorCode"`
+	Diagnostic       string `json:"diagnostic"`
+	Code             string `json:"code"`
+	Message          string `json:"message"`
+	Errors           []struct {
+		Code   string `json:"code"`
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	} `json:"errors"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"msg"`
+	} `json:"Error"`
+}
+
+// NewAPIError returns the error reported by response, reading what is left of its body.
+// The caller remains responsible for closing the body.
+func NewAPIError(response *http.Response) *APIError {
+	body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorBody))
+
+	err := &APIError{
+		StatusCode:        response.StatusCode,
+		Body:              body,
+		RequestID:         response.Header.Get("X-Ads-Request-Id"),
+		TroubleshootingID: response.Header.Get("X-Ads-Troubleshooting-Id"),
+	}
+	if len(err.RequestID) == 0 {
+		err.RequestID = response.Header.Get("X-Request-Id")
+	}
+	if response.Request != nil {
+		err.Method = response.Request.Method
+		err.URL = response.Request.URL.Redacted()
+	}
+
+	// the fields are filled as far as the body could be parsed, it may not even be JSON
+	var parsed errorBody
+	json.Unmarshal(body, &parsed)
+	err.Code, err.Message = parsed.codeAndMessage()
+
+	return err
+}
+
+// codeAndMessage returns the first code and message found in the body
+func (b errorBody) codeAndMessage() (code string, message string) {
+	switch {
+	case b.Error != nil:
+		return b.Error.Code, b.Error.Message
+	case len(b.Errors) != 0:
+		message = b.Errors[0].Detail
+		if len(message) == 0 {
+			message = b.Errors[0].Title
+		}
+		return b.Errors[0].Code, message
+	}
+
+	code = b.ErrorCode
+	if len(code) == 0 {
+		code = b.Code
+	}
+	for _, candidate := range []string{b.DeveloperMessage, b.Reason, b.Diagnostic, b.Message} {
+		if len(candidate) != 0 {
+			return code, candidate
+		}
+	}
+
+	return code, ""
+}
+
+// Error keeps the format of the errors previously returned: the status followed by the body,
+// or the code followed by the message for the errors sent in successful responses
+func (e *APIError) Error() string {
+	if len(e.Body) == 0 && len(e.Code) != 0 {
+		return "[" + e.Code + "] " + e.Message
+	}
+
+	return "[" + strconv.Itoa(e.StatusCode) + "] " + string(e.Body)
+}
+
+// Is reports whether the error matches one of the sentinel errors of this package
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrQuotaExceeded:
+		return e.StatusCode == http.StatusTooManyRequests ||
+			strings.Contains(strings.ToLower(e.Code+" "+e.Message), "quota")
+	}
+
+	return false
+}
+
+// IsNotFound reports whether err is, or wraps, an APIError for a missing resource
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsConflict reports whether err is, or wraps, an APIError for a resource that already exists or was modified
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// IsQuotaExceeded reports whether err is, or wraps, an APIError for a rate limit or a quota being exceeded
+func IsQuotaExceeded(err error) bool {
+	return errors.Is(err, ErrQuotaExceeded)
+}
+
+// IsUnauthorized reports whether err is, or wraps, an APIError for a missing or invalid token
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err is, or wraps, an APIError for a token lacking permissions on the resource
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
@@ -0,0 +1,107 @@
+package forge_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"github.com/outer-labs/forge-api-go-client/dm"
+)
+
+func TestNewAPIError(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		code    string
+		message string
+	}{
+		{"OSS", http.StatusConflict, `{"reason":"Bucket already exists"}`, "", "Bucket already exists"},
+		{"Data Management", http.StatusNotFound, `{"jsonapi":{"version":"1.0"},"errors":[{"status":"404","code":"ERR_NOT_FOUND","detail":"The item was not found"}]}`, "ERR_NOT_FOUND", "The item was not found"},
+		{"Model Derivative", http.StatusBadRequest, `{"diagnostic":"Failed to trigger translation"}`, "", "Failed to trigger translation"},
+		{"Reality Capture", http.StatusOK, `{"Error":{"code":"18","msg":"Scene not found"}}`, "18", "Scene not found"},
+		{"Developer message", http.StatusForbidden, `{"developerMessage":"Token lacks scope","errorCode":"AUTH-012"}`, "AUTH-012", "Token lacks scope"},
+		{"Not JSON", http.StatusBadGateway, `<html>Bad gateway</html>`, "", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Ads-Troubleshooting-Id", "troubleshooting")
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			response, err := forge.NewClient(forge.WithMiddleware(forge.Retry(forge.NoRetry))).Get(server.URL + "/resource")
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			defer response.Body.Close()
+
+			apiErr := forge.NewAPIError(response)
+			if apiErr.Code != test.code || apiErr.Message != test.message {
+				t.Errorf("Expected code %q and message %q, got %q and %q", test.code, test.message, apiErr.Code, apiErr.Message)
+			}
+			if string(apiErr.Body) != test.body || apiErr.StatusCode != test.status {
+				t.Errorf("Expected the status and the raw body to be kept, got %d %q", apiErr.StatusCode, apiErr.Body)
+			}
+			if apiErr.Method != "GET" || apiErr.URL != server.URL+"/resource" || apiErr.TroubleshootingID != "troubleshooting" {
+				t.Errorf("Unexpected request details: %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestAPIError_Is(t *testing.T) {
+
+	wrapped := fmt.Errorf("could not get the item: %w", &forge.APIError{StatusCode: http.StatusNotFound})
+
+	if !forge.IsNotFound(wrapped) || forge.IsConflict(wrapped) {
+		t.Error("Expected a wrapped 404 to be reported as not found only")
+	}
+
+	var apiErr *forge.APIError
+	if !errors.As(wrapped, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Error("Expected the APIError to be extracted with errors.As")
+	}
+
+	if !forge.IsQuotaExceeded(&forge.APIError{StatusCode: http.StatusTooManyRequests}) {
+		t.Error("Expected a 429 to be reported as a quota exceeded")
+	}
+	if !forge.IsQuotaExceeded(&forge.APIError{StatusCode: http.StatusForbidden, Code: "ERR_QUOTA_EXCEEDED"}) {
+		t.Error("Expected a quota error code to be reported as a quota exceeded")
+	}
+	if forge.IsNotFound(errors.New("[404] not found")) {
+		t.Error("Expected only an APIError to match")
+	}
+}
+
+func TestAPIError_ReturnedByDM(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/authentication/v1/authenticate" {
+			w.Write([]byte(`{"access_token":"access","expires_in":3599}`))
+			return
+		}
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(`{"reason":"Bucket already exists"}`))
+	}))
+	defer server.Close()
+
+	api := dm.NewBucketAPIWithCredentials("client", "secret", dm.DefaultRateLimiter)
+	api.Client = forge.NewClient(forge.WithHost(server.URL))
+
+	_, err := api.CreateBucket(context.Background(), "bucket", "transient")
+	if !forge.IsConflict(err) {
+		t.Fatalf("Expected a conflict, got %v", err)
+	}
+	if err.Error() != `[409] {"reason":"Bucket already exists"}` {
+		t.Errorf("Unexpected message: %s", err.Error())
+	}
+}
@@ -0,0 +1,192 @@
+package forge
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// DefaultHost is the host serving the Forge APIs
+const DefaultHost = "https://developer.api.autodesk.com"
+
+// DefaultUserAgent is sent with every request unless the Client specifies another one
+const DefaultUserAgent = "forge-api-go-client"
+
+// Middleware wraps a RoundTripper, e.g. to modify the requests or observe the responses
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc is a function acting as a RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip calls f(req)
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Client is the core shared by the API structs of the dm, md, recap and oauth packages to send their requests.
+// It holds the http.Client, allowing to configure proxies, TLS, timeouts and connection reuse in one place,
+// and a chain of middlewares every request goes through.
+//
+// The requests failing with a transient error are retried with DefaultRetryPolicy, unless the chain has a Retry
+// middleware, see Retry. A nil *Client is valid and behaves as a Client with default settings.
+type Client struct {
+	// HTTPClient sends the requests, http.DefaultClient if nil. Its Transport is wrapped by the middlewares.
+	HTTPClient *http.Client
+	// Host, if set, replaces the scheme and host of every request, e.g. to go through a gateway or a test server.
+	// It is replaced after the middlewares, so that they see the URLs of the Forge APIs.
+	Host string
+	// UserAgent is sent in the User-Agent header of the requests that do not set one
+	UserAgent string
+
+	middlewares []Middleware
+
+	mutex   sync.Mutex   // Guards chained
+	chained *http.Client // HTTPClient sending through the middlewares, built on first use
+}
+
+// Option configures a Client built by NewClient
+type Option func(*Client)
+
+// WithHTTPClient makes the Client send its requests with httpClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithHost makes the Client send its requests to host instead of the one of each request
+func WithHost(host string) Option {
+	return func(c *Client) {
+		c.Host = host
+	}
+}
+
+// WithUserAgent sets the User-Agent of the Client
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithMiddleware adds middlewares to the Client, see Client.Use
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(c *Client) {
+		c.Use(middlewares...)
+	}
+}
+
+// NewClient returns a Client using its own http.Client, so that its settings do not affect http.DefaultClient
+func NewClient(options ...Option) *Client {
+	client := &Client{
+		HTTPClient: &http.Client{},
+		UserAgent:  DefaultUserAgent,
+	}
+	for _, option := range options {
+		option(client)
+	}
+
+	return client
+}
+
+// Use adds middlewares to the chain. The first middleware added is the outermost one:
+// it sees the request first and the response last.
+func (c *Client) Use(middlewares ...Middleware) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.middlewares = append(c.middlewares, middlewares...)
+	c.chained = nil
+}
+
+// Do sends the request through the middlewares and the http.Client.
+// The chain is built on the first request: HTTPClient, Host and UserAgent must not be changed afterwards.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	return c.httpClient().Do(req)
+}
+
+// defaultClient is the http.Client of a nil *Client
+var (
+	defaultClientOnce sync.Once
+	defaultClient     *http.Client
+)
+
+// httpClient returns the http.Client sending the requests through the chain, building it if needed
+func (c *Client) httpClient() *http.Client {
+	if c == nil {
+		defaultClientOnce.Do(func() {
+			defaultClient = chain(http.DefaultClient, nil, "", DefaultUserAgent)
+		})
+		return defaultClient
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.chained == nil {
+		httpClient := http.DefaultClient
+		if c.HTTPClient != nil {
+			httpClient = c.HTTPClient
+		}
+		c.chained = chain(httpClient, c.middlewares, c.Host, c.UserAgent)
+	}
+
+	return c.chained
+}
+
+// Get sends a GET request to url, see Do
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// chain returns a copy of httpClient whose transport sends the requests through the default retries,
+// the middlewares, then the settings of the Client. The host is replaced last, so that the middlewares,
+// such as RateLimit, see the URLs of the Forge APIs.
+func chain(httpClient *http.Client, middlewares []Middleware, host, userAgent string) *http.Client {
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	transport = withDefaults(transport, host, userAgent)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		transport = middlewares[i](transport)
+	}
+
+	chained := *httpClient
+	chained.Transport = Retry(DefaultRetryPolicy)(transport)
+
+	return &chained
+}
+
+// withDefaults sets the User-Agent and the host of the requests before passing them to next
+func withDefaults(next http.RoundTripper, host string, userAgent string) http.RoundTripper {
+	var target *url.URL
+	if len(host) != 0 {
+		target, _ = url.Parse(host)
+	}
+
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		_, hasUserAgent := req.Header["User-Agent"]
+		if hasUserAgent && target == nil {
+			return next.RoundTrip(req)
+		}
+
+		// a RoundTripper must not modify the request it receives
+		req = req.Clone(req.Context())
+		if !hasUserAgent && len(userAgent) != 0 {
+			req.Header.Set("User-Agent", userAgent)
+		}
+		if target != nil {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.Host = target.Host
+		}
+
+		return next.RoundTrip(req)
+	})
+}
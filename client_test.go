@@ -0,0 +1,289 @@
+package forge_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"github.com/outer-labs/forge-api-go-client/dm"
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// recordingServer responds 200 and records the last request it received
+func recordingServer(last **http.Request) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = r
+		w.Write([]byte(`{}`))
+	}))
+}
+
+func TestClient_Do(t *testing.T) {
+
+	var received *http.Request
+	server := recordingServer(&received)
+	defer server.Close()
+
+	t.Run("Host and User-Agent", func(t *testing.T) {
+		client := forge.NewClient(forge.WithHost(server.URL), forge.WithUserAgent("test-agent"))
+
+		response, err := client.Get(forge.DefaultHost + "/oss/v2/buckets?limit=1")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if received.URL.Path != "/oss/v2/buckets" || received.URL.RawQuery != "limit=1" {
+			t.Errorf("Expected the request to be sent to the test server, got %s", received.URL)
+		}
+		if received.UserAgent() != "test-agent" {
+			t.Errorf("Expected the User-Agent to be set, got %q", received.UserAgent())
+		}
+	})
+
+	t.Run("Nil client", func(t *testing.T) {
+		var client *forge.Client
+
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if received.UserAgent() != forge.DefaultUserAgent {
+			t.Errorf("Expected the default User-Agent, got %q", received.UserAgent())
+		}
+	})
+
+	t.Run("Middleware order", func(t *testing.T) {
+		var order []string
+		trace := func(name string) forge.Middleware {
+			return func(next http.RoundTripper) http.RoundTripper {
+				return forge.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					order = append(order, name)
+					return next.RoundTrip(req)
+				})
+			}
+		}
+
+		client := forge.NewClient(forge.WithMiddleware(trace("first")))
+		client.Use(trace("second"))
+
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if strings.Join(order, ",") != "first,second" {
+			t.Errorf("Expected the middlewares to run in the order they were added, got %v", order)
+		}
+	})
+
+	t.Run("Host replaced after the middlewares", func(t *testing.T) {
+		var seen string
+		client := forge.NewClient(forge.WithHost(server.URL), forge.WithMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return forge.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				seen = req.URL.String()
+				return next.RoundTrip(req)
+			})
+		}))
+
+		response, err := client.Get(forge.DefaultHost + "/oss/v2/buckets")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if seen != forge.DefaultHost+"/oss/v2/buckets" {
+			t.Errorf("Expected the middlewares to see the Forge URL, got %s", seen)
+		}
+		if received.URL.Path != "/oss/v2/buckets" {
+			t.Errorf("Expected the request to be sent to the test server, got %s", received.URL)
+		}
+	})
+
+	t.Run("Chain built once", func(t *testing.T) {
+		built := 0
+		counting := func(next http.RoundTripper) http.RoundTripper {
+			built++
+			return next
+		}
+
+		client := forge.NewClient(forge.WithMiddleware(counting))
+		for i := 0; i < 3; i++ {
+			response, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			response.Body.Close()
+		}
+		if built != 1 {
+			t.Errorf("Expected the chain to be built once, got %d times", built)
+		}
+
+		client.Use(counting)
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+		if built != 3 {
+			t.Errorf("Expected the chain to be built again with the new middleware, got %d builds", built)
+		}
+	})
+}
+
+func TestBearer(t *testing.T) {
+
+	var received *http.Request
+	server := recordingServer(&received)
+	defer server.Close()
+
+	client := forge.NewClient(forge.WithMiddleware(forge.Bearer(forge.TokenSourceFunc(func(ctx context.Context) (string, error) {
+		return "access", nil
+	}))))
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	response.Body.Close()
+
+	if received.Header.Get("Authorization") != "Bearer access" {
+		t.Errorf("Expected the token to be sent, got %q", received.Header.Get("Authorization"))
+	}
+
+	t.Run("Existing Authorization", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req.Header.Set("Authorization", "Bearer other")
+
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if received.Header.Get("Authorization") != "Bearer other" {
+			t.Errorf("Expected the Authorization of the request to be kept, got %q", received.Header.Get("Authorization"))
+		}
+	})
+}
+
+// failingLimiter refuses every request
+type failingLimiter struct{}
+
+func (failingLimiter) Wait(ctx context.Context, method string, url string) error {
+	return context.DeadlineExceeded
+}
+
+func TestRateLimit(t *testing.T) {
+
+	var received *http.Request
+	server := recordingServer(&received)
+	defer server.Close()
+
+	client := forge.NewClient(forge.WithMiddleware(forge.RateLimit(failingLimiter{})))
+
+	if _, err := client.Get(server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the limiter error to be returned, got %v", err)
+	}
+	if received != nil {
+		t.Error("Expected the request not to be sent")
+	}
+}
+
+// observingLimiter records the responses reported to it
+type observingLimiter struct {
+	statuses []int
+}
+
+func (l *observingLimiter) ObserveResponse(req *http.Request, response *http.Response) {
+	l.statuses = append(l.statuses, response.StatusCode)
+}
+
+func TestDo(t *testing.T) {
+
+	var received *http.Request
+	server := recordingServer(&received)
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	limiter := &observingLimiter{}
+	response, err := forge.Do(nil, limiter, req)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	response.Body.Close()
+
+	if len(limiter.statuses) != 1 || limiter.statuses[0] != http.StatusOK {
+		t.Errorf("Expected the response to be reported to the limiter, got %v", limiter.statuses)
+	}
+
+	if _, err := forge.Do(nil, failingLimiter{}, req); err != nil {
+		t.Errorf("Expected a limiter that does not observe the responses to be ignored, got %v", err)
+	}
+}
+
+func TestLogging(t *testing.T) {
+
+	var received *http.Request
+	server := recordingServer(&received)
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	client := forge.NewClient(
+		forge.WithMiddleware(forge.Logging(log.New(output, "", 0))),
+		forge.WithMiddleware(forge.Bearer(forge.TokenSourceFunc(func(ctx context.Context) (string, error) {
+			return "secret-token", nil
+		}))),
+	)
+
+	response, err := client.Get(server.URL + "/path")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	response.Body.Close()
+
+	logged := output.String()
+	if !strings.HasPrefix(logged, "GET "+server.URL+"/path 200 ") {
+		t.Errorf("Unexpected log: %q", logged)
+	}
+	if strings.Contains(logged, "secret-token") {
+		t.Errorf("Expected the token not to be logged: %q", logged)
+	}
+}
+
+func TestClient_SharedByAPIs(t *testing.T) {
+
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		if strings.HasPrefix(r.URL.Path, "/authentication/") {
+			json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "access", ExpiresIn: 3599})
+			return
+		}
+		json.NewEncoder(w).Encode(dm.BucketDetails{BucketKey: "bucket"})
+	}))
+	defer server.Close()
+
+	api := dm.NewBucketAPIWithCredentials("client", "secret", dm.DefaultRateLimiter)
+	api.Client = forge.NewClient(forge.WithHost(server.URL), forge.WithUserAgent("shared"))
+
+	details, err := api.GetBucketDetails(context.Background(), "bucket")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if details.BucketKey != "bucket" {
+		t.Errorf("Unexpected details: %+v", details)
+	}
+	if strings.Join(userAgents, ",") != "shared,shared" {
+		t.Errorf("Expected the authentication and the API call to go through the client, got %v", userAgents)
+	}
+}
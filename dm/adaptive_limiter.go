@@ -0,0 +1,189 @@
+package dm
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"golang.org/x/time/rate"
+)
+
+// DefaultThrottlePause is how long an endpoint is paused after a 429 response that does not say when to retry
+var DefaultThrottlePause = 5 * time.Second
+
+// LimitState describes an endpoint whose limit was adjusted after the server throttled the requests
+type LimitState struct {
+	Method string
+	// Endpoint is the pattern of the URLs sharing the limit, "oss" or "fallback"
+	Endpoint string
+	// Limit is the current number of requests per second, BaseLimit the configured one
+	Limit     rate.Limit
+	BaseLimit rate.Limit
+	// PausedUntil is the time before which no request is sent to the endpoint
+	PausedUntil time.Time
+	// Throttled counts the 429 responses received for the endpoint
+	Throttled int
+}
+
+// ObserveResponse adjusts the limit of the endpoint of req to what the server says in response.
+// On a 429 response, or when a X-RateLimit-Remaining header reaches 0, the endpoint is paused for the duration
+// given by the Retry-After or X-RateLimit-Reset header, and a 429 also halves its limit.
+// Each successful response then restores a tenth of the configured limit.
+//
+// The requests made by the dm, md, recap and oauth packages are reported automatically,
+// as are the ones going through the forge.RateLimit middleware.
+func (r *RateLimiter) ObserveResponse(req *http.Request, response *http.Response) {
+	if response == nil {
+		return
+	}
+	limiter, method, endpoint := r.endpoint(req.Method, req.URL.String())
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	state, adjusted := r.adjusted[limiter]
+	pause, paused := throttlePause(response)
+
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		if !adjusted {
+			state = r.track(limiter, method, endpoint)
+		}
+		if !paused {
+			pause = DefaultThrottlePause
+		}
+		state.Throttled++
+		state.pause(pause)
+		limiter.SetLimit(maxLimit(state.Limit/2, state.BaseLimit/16))
+
+	case paused:
+		if !adjusted {
+			state = r.track(limiter, method, endpoint)
+		}
+		state.pause(pause)
+
+	case adjusted && response.StatusCode < http.StatusBadRequest && state.Limit < state.BaseLimit:
+		limiter.SetLimit(minLimit(state.Limit+state.BaseLimit/10, state.BaseLimit))
+	}
+
+	if state != nil {
+		state.Limit = limiter.Limit()
+	}
+}
+
+// State returns the endpoints whose limit was adjusted, sorted by endpoint and method
+func (r *RateLimiter) State() []LimitState {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	states := make([]LimitState, 0, len(r.adjusted))
+	for _, state := range r.adjusted {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Endpoint != states[j].Endpoint {
+			return states[i].Endpoint < states[j].Endpoint
+		}
+		return states[i].Method < states[j].Method
+	})
+
+	return states
+}
+
+// track starts recording the adjustments of limiter, r.mutex being held
+func (r *RateLimiter) track(limiter *rate.Limiter, method, endpoint string) *LimitState {
+	if r.adjusted == nil {
+		r.adjusted = make(map[*rate.Limiter]*LimitState)
+	}
+	state := &LimitState{
+		Method:    method,
+		Endpoint:  endpoint,
+		Limit:     limiter.Limit(),
+		BaseLimit: limiter.Limit(),
+	}
+	r.adjusted[limiter] = state
+
+	return state
+}
+
+// baseLimit returns the configured limit of limiter, without the adjustments to the 429 responses
+func (r *RateLimiter) baseLimit(limiter *rate.Limiter) rate.Limit {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if state, ok := r.adjusted[limiter]; ok {
+		return state.BaseLimit
+	}
+
+	return limiter.Limit()
+}
+
+// waitPause blocks until the pause of limiter, if any, is over or ctx is done
+func (r *RateLimiter) waitPause(ctx context.Context, limiter *rate.Limiter) error {
+	r.mutex.Lock()
+	var until time.Time
+	if state, ok := r.adjusted[limiter]; ok {
+		until = state.PausedUntil
+	}
+	r.mutex.Unlock()
+
+	wait := time.Until(until)
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// pause extends the pause of the endpoint to at least d from now
+func (s *LimitState) pause(d time.Duration) {
+	if until := time.Now().Add(d); until.After(s.PausedUntil) {
+		s.PausedUntil = until
+	}
+}
+
+// throttlePause returns how long the server asks to wait, with Retry-After, or when X-RateLimit-Remaining is 0,
+// with X-RateLimit-Reset given either in seconds or as a Unix time
+func throttlePause(response *http.Response) (time.Duration, bool) {
+	if pause, ok := forge.RetryAfter(response); ok {
+		return pause, true
+	}
+	if response.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+
+	reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return DefaultThrottlePause, true
+	}
+	// values beyond a year of seconds can only be Unix times
+	if reset > 365*24*3600 {
+		return time.Until(time.Unix(reset, 0)), true
+	}
+
+	return time.Duration(reset) * time.Second, true
+}
+
+func minLimit(a, b rate.Limit) rate.Limit {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxLimit(a, b rate.Limit) rate.Limit {
+	if a > b {
+		return a
+	}
+	return b
+}
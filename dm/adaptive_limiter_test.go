@@ -0,0 +1,107 @@
+package dm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"golang.org/x/time/rate"
+)
+
+// observe reports a response with the given status and headers for a GET of url
+func observe(r *RateLimiter, url string, status int, headers map[string]string) {
+	req, _ := http.NewRequest("GET", url, nil)
+	response := &http.Response{StatusCode: status, Header: http.Header{}, Request: req}
+	for key, value := range headers {
+		response.Header.Set(key, value)
+	}
+	r.ObserveResponse(req, response)
+}
+
+func TestRateLimiter_ObserveResponse(t *testing.T) {
+
+	const url = "https://developer.api.autodesk.com/data/v1/projects/p/folders/f"
+
+	endpoints := ApiEndpoints{"GET": {apiUrlRegexp(`projects/{project_id}/folders/{folder_id}$`): limitPerMinute(600)}}
+	limiter := NewRateLimiter(&endpoints, &DefaultOSSLimiter, limitPerMinute(50))
+	base := rate.Limit(10)
+
+	observe(limiter, url, http.StatusOK, nil)
+	if len(limiter.State()) != 0 {
+		t.Fatalf("Expected no adjustment before being throttled, got %+v", limiter.State())
+	}
+
+	observe(limiter, url, http.StatusTooManyRequests, map[string]string{"Retry-After": "1"})
+
+	states := limiter.State()
+	if len(states) != 1 {
+		t.Fatalf("Expected the endpoint to be adjusted, got %+v", states)
+	}
+	state := states[0]
+	if state.Method != "GET" || state.Throttled != 1 || state.BaseLimit != base || state.Limit != base/2 {
+		t.Errorf("Expected the limit to be halved, got %+v", state)
+	}
+	if until := time.Until(state.PausedUntil); until <= 0 || until > time.Second {
+		t.Errorf("Expected a pause of a second, got %s", until)
+	}
+
+	t.Run("Paused endpoint", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := limiter.Wait(ctx, "GET", url); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the request to wait for the end of the pause, got %v", err)
+		}
+	})
+
+	t.Run("Gradual restore", func(t *testing.T) {
+		for i := 0; i < 4; i++ {
+			observe(limiter, url, http.StatusOK, nil)
+		}
+		if limit := limiter.State()[0].Limit; limit != base*9/10 {
+			t.Errorf("Expected a tenth of the limit to be restored per success, got %v", limit)
+		}
+
+		for i := 0; i < 10; i++ {
+			observe(limiter, url, http.StatusOK, nil)
+		}
+		if limit := limiter.State()[0].Limit; limit != base {
+			t.Errorf("Expected the configured limit to be restored, got %v", limit)
+		}
+	})
+
+	t.Run("Rate limit headers", func(t *testing.T) {
+		observe(limiter, url, http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "30"})
+
+		state := limiter.State()[0]
+		if state.Limit != base || time.Until(state.PausedUntil) < 29*time.Second {
+			t.Errorf("Expected a pause until the reset without lowering the limit, got %+v", state)
+		}
+	})
+}
+
+func TestRateLimiter_RateLimitMiddleware(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(&ApiEndpoints{}, &DefaultOSSLimiter, rate.NewLimiter(rate.Inf, 1))
+	client := forge.NewClient(forge.WithMiddleware(forge.Retry(forge.NoRetry), forge.RateLimit(limiter)))
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	response.Body.Close()
+
+	states := limiter.State()
+	if len(states) != 1 || states[0].Endpoint != "fallback" || states[0].Throttled != 1 {
+		t.Errorf("Expected the middleware to report the 429, got %+v", states)
+	}
+}
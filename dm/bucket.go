@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 
+	forge "github.com/outer-labs/forge-api-go-client"
 	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
@@ -45,6 +46,8 @@ type BucketDetails struct {
 }
 
 // ErrorResult reflects the body content when a request failed (g.e. Bad request or key conflict)
+//
+// Deprecated: the calls now return a *forge.APIError, which also holds the Forge error code and the request IDs.
 type ErrorResult struct {
 	Reason     string `json:"reason"`
 	StatusCode int
@@ -71,7 +74,7 @@ func (api BucketAPI) CreateBucket(ctx context.Context, bucketKey, policyKey stri
 		return
 	}
 	path := api.Host + api.BucketAPIPath
-	result, err = createBucket(ctx, api.RateLimiter, path, bucketKey, policyKey, bearer.AccessToken)
+	result, err = createBucket(ctx, api.Client, api.RateLimiter, path, bucketKey, policyKey, bearer.AccessToken)
 
 	return
 }
@@ -85,7 +88,7 @@ func (api BucketAPI) DeleteBucket(ctx context.Context, bucketKey string) error {
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return deleteBucket(ctx, api.RateLimiter, path, bucketKey, bearer.AccessToken)
+	return deleteBucket(ctx, api.Client, api.RateLimiter, path, bucketKey, bearer.AccessToken)
 }
 
 // ListBuckets returns a list of all buckets created or associated with Forge secrets used for token creation
@@ -96,7 +99,7 @@ func (api BucketAPI) ListBuckets(ctx context.Context, region, limit, startAt str
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return listBuckets(ctx, api.RateLimiter, path, region, limit, startAt, bearer.AccessToken)
+	return listBuckets(ctx, api.Client, api.RateLimiter, path, region, limit, startAt, bearer.AccessToken)
 }
 
 // GetBucketDetails returns information associated to a bucket. See BucketDetails struct.
@@ -107,16 +110,14 @@ func (api BucketAPI) GetBucketDetails(ctx context.Context, bucketKey string) (re
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return getBucketDetails(ctx, api.RateLimiter, path, bucketKey, bearer.AccessToken)
+	return getBucketDetails(ctx, api.Client, api.RateLimiter, path, bucketKey, bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
-func getBucketDetails(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, token string) (result BucketDetails, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func getBucketDetails(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, token string) (result BucketDetails, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+bucketKey+"/details",
 		nil,
 	)
@@ -126,7 +127,7 @@ func getBucketDetails(ctx context.Context, limiter HttpRequestLimiter, path, buc
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -134,8 +135,7 @@ func getBucketDetails(ctx context.Context, limiter HttpRequestLimiter, path, buc
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -144,10 +144,8 @@ func getBucketDetails(ctx context.Context, limiter HttpRequestLimiter, path, buc
 	return
 }
 
-func listBuckets(ctx context.Context, limiter HttpRequestLimiter, path, region, limit, startAt, token string) (result ListedBuckets, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func listBuckets(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, region, limit, startAt, token string) (result ListedBuckets, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path,
 		nil,
 	)
@@ -170,15 +168,14 @@ func listBuckets(ctx context.Context, limiter HttpRequestLimiter, path, region,
 	req.URL.RawQuery = params.Encode()
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -187,9 +184,7 @@ func listBuckets(ctx context.Context, limiter HttpRequestLimiter, path, region,
 	return
 }
 
-func createBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, policyKey, token string) (result BucketDetails, err error) {
-
-	task := http.Client{}
+func createBucket(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, policyKey, token string) (result BucketDetails, err error) {
 
 	body, err := json.Marshal(
 		CreateBucketRequest{
@@ -200,7 +195,7 @@ func createBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 		return
 	}
 
-	req, err := limiter.HttpRequest(ctx, "POST",
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path,
 		bytes.NewReader(body),
 	)
@@ -210,7 +205,7 @@ func createBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -218,8 +213,7 @@ func createBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -228,10 +222,8 @@ func createBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 	return
 }
 
-func deleteBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, token string) (err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "DELETE",
+func deleteBucket(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, token string) (err error) {
+	req, err := forge.NewRequest(ctx, limiter, "DELETE",
 		path+"/"+bucketKey,
 		nil,
 	)
@@ -241,15 +233,13 @@ func deleteBucket(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
-	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -31,7 +31,7 @@ func (api BucketAPI3L) CreateBucket3L(ctx context.Context, bucketKey, policyKey
 	}
 
 	path := api.Auth.Host + api.BucketsAPIPath
-	result, err = createBucket(ctx, api.RateLimiter, path, bucketKey, policyKey, api.Token.Bearer().AccessToken)
+	result, err = createBucket(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, policyKey, api.Token.Bearer().AccessToken)
 
 	return
 }
@@ -45,7 +45,7 @@ func (api BucketAPI3L) DeleteBucket3L(ctx context.Context, bucketKey string) err
 
 	path := api.Auth.Host + api.BucketsAPIPath
 
-	return deleteBucket(ctx, api.RateLimiter, path, bucketKey, api.Token.Bearer().AccessToken)
+	return deleteBucket(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, api.Token.Bearer().AccessToken)
 }
 
 // ListBuckets returns a list of all buckets created or associated with Forge secrets used for token creation
@@ -56,7 +56,7 @@ func (api BucketAPI3L) ListBuckets3L(ctx context.Context, region, limit, startAt
 
 	path := api.Auth.Host + api.BucketsAPIPath
 
-	return listBuckets(ctx, api.RateLimiter, path, region, limit, startAt, api.Token.Bearer().AccessToken)
+	return listBuckets(ctx, api.Auth.Client, api.RateLimiter, path, region, limit, startAt, api.Token.Bearer().AccessToken)
 }
 
 // GetBucketDetails returns information associated to a bucket. See BucketDetails struct.
@@ -66,5 +66,5 @@ func (api BucketAPI3L) GetBucketDetails3L(ctx context.Context, bucketKey string)
 	}
 
 	path := api.Auth.Host + api.BucketsAPIPath
-	return getBucketDetails(ctx, api.RateLimiter, path, bucketKey, api.Token.Bearer().AccessToken)
+	return getBucketDetails(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, api.Token.Bearer().AccessToken)
 }
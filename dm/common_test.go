@@ -0,0 +1,39 @@
+package dm
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestForgeResponseObject_Unmarshal(t *testing.T) {
+
+	body := `{
+		"jsonApi": {"version": "1.0"},
+		"data": {
+			"type": "items",
+			"id": "urn:item",
+			"attributes": {"displayName": "model.rvt", "hidden": true},
+			"relationships": {"tip": {"data": {"type": "versions", "id": "urn:version"}}}
+		},
+		"included": [{"type": "versions", "id": "urn:version"}]
+	}`
+
+	var response ForgeResponseObject
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	attributes := response.Data.Attributes
+	if attributes == nil || attributes.DisplayName == nil || *attributes.DisplayName != "model.rvt" {
+		t.Errorf("Expected the display name to be decoded, got %+v", attributes)
+	}
+	if attributes == nil || attributes.Hidden == nil || !*attributes.Hidden {
+		t.Errorf("Expected the hidden flag to be decoded, got %+v", attributes)
+	}
+	if response.JsonApi.Version != "1.0" || response.Included == nil || len(*response.Included) != 1 {
+		t.Errorf("Expected jsonApi and included to be decoded, got %+v", response)
+	}
+	if tip := response.Data.Relationships.Tip; tip == nil || tip.Data == nil || tip.Data.Id != "urn:version" {
+		t.Errorf("Expected the relationships to be decoded, got %+v", response.Data.Relationships)
+	}
+}
@@ -0,0 +1,148 @@
+package dm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// BucketStore holds token buckets shared by several processes, so that they respect one budget together.
+// Implementations backed by a shared database, such as Redis, must take the tokens atomically.
+type BucketStore interface {
+	// Take reserves a token of the bucket key, refilled at limit tokens per second up to burst tokens,
+	// and returns how long to wait before using it. If the wait would exceed the deadline of ctx, no token
+	// is reserved and the error wraps context.DeadlineExceeded.
+	Take(ctx context.Context, key string, limit rate.Limit, burst int) (time.Duration, error)
+}
+
+// DistributedRateLimiter is a HttpRequestLimiter taking its tokens from a BucketStore shared by the replicas
+// of an application, as the Forge rate limits apply to the application and not to each process.
+// The endpoints and their limits are those of Limits, each endpoint pattern having one bucket in the store.
+type DistributedRateLimiter struct {
+	Store BucketStore
+	// Limits finds the endpoint of the requests and its configured limit. Its adjustments to the 429 responses
+	// are local to the process: the shared buckets keep the configured limits.
+	Limits *RateLimiter
+	// Prefix is prepended to the keys of the buckets, so that several applications can share a store
+	Prefix string
+}
+
+// NewDistributedRateLimiter returns a limiter sharing the limits of DefaultRateLimiter through store
+func NewDistributedRateLimiter(store BucketStore, prefix string) *DistributedRateLimiter {
+	return &DistributedRateLimiter{
+		Store:  store,
+		Limits: DefaultRateLimiter,
+		Prefix: prefix,
+	}
+}
+
+func (d *DistributedRateLimiter) HttpRequest(
+	ctx context.Context,
+	method string,
+	url string,
+	body io.Reader,
+) (*http.Request, error) {
+	if err := d.Wait(ctx, method, url); err != nil {
+		return nil, fmt.Errorf("rate limit wait: %w", err)
+	}
+
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+// Wait blocks until the shared bucket of the endpoint matching method and url allows a request.
+// It fails without waiting if the wait would exceed the deadline of ctx.
+func (d *DistributedRateLimiter) Wait(ctx context.Context, method string, url string) error {
+	limiter, method, endpoint := d.Limits.endpoint(method, url)
+	if err := d.Limits.waitPause(ctx, limiter); err != nil {
+		return err
+	}
+
+	wait, err := d.Store.Take(ctx, d.Prefix+method+" "+endpoint, d.Limits.baseLimit(limiter), limiter.Burst())
+	if err != nil {
+		return fmt.Errorf("could not take a token: %w", err)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// ObserveResponse passes the response to Limits, see RateLimiter.ObserveResponse
+func (d *DistributedRateLimiter) ObserveResponse(req *http.Request, response *http.Response) {
+	d.Limits.ObserveResponse(req, response)
+}
+
+// MemoryBucketStore is a BucketStore keeping the buckets in memory, for tests and for limiters
+// shared by the goroutines of a single process
+type MemoryBucketStore struct {
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryBucketStore returns an empty MemoryBucketStore
+func NewMemoryBucketStore() *MemoryBucketStore {
+	return &MemoryBucketStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take refills the bucket for the time elapsed since the previous call, then takes a token from it.
+// The tokens can go negative, the wait being the time needed to refill them, unless the wait would exceed
+// the deadline of ctx.
+func (s *MemoryBucketStore) Take(ctx context.Context, key string, limit rate.Limit, burst int) (time.Duration, error) {
+	if limit == rate.Inf {
+		return 0, nil
+	}
+	if limit <= 0 || burst <= 0 {
+		return 0, fmt.Errorf("the bucket %s allows no request", key)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(float64(burst), b.tokens+elapsed*float64(limit))
+	b.updated = now
+
+	remaining := b.tokens - 1
+	if remaining >= 0 {
+		b.tokens = remaining
+		return 0, nil
+	}
+
+	wait := time.Duration(-remaining / float64(limit) * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && wait > time.Until(deadline) {
+		return wait, fmt.Errorf("waiting %s for the bucket %s would exceed the context deadline: %w", wait, key, context.DeadlineExceeded)
+	}
+	b.tokens = remaining
+
+	return wait, nil
+}
@@ -0,0 +1,142 @@
+package dm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestMemoryBucketStore_Take(t *testing.T) {
+
+	now := time.Now()
+	store := NewMemoryBucketStore()
+	store.now = func() time.Time { return now }
+
+	expectWait := func(expected time.Duration) {
+		t.Helper()
+		wait, err := store.Take(context.Background(), "key", 10, 2)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if wait != expected {
+			t.Errorf("Expected to wait %s, got %s", expected, wait)
+		}
+	}
+
+	expectWait(0)
+	expectWait(0)
+	expectWait(100 * time.Millisecond)
+	expectWait(200 * time.Millisecond)
+
+	now = now.Add(time.Second)
+	expectWait(0)
+
+	if wait, _ := store.Take(context.Background(), "other", 10, 2); wait != 0 {
+		t.Errorf("Expected the buckets to be independent, got %s", wait)
+	}
+
+	// a wait beyond the deadline fails without reserving a token
+	now = now.Add(time.Second)
+	expectWait(0)
+	expectWait(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := store.Take(ctx, "key", 10, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a wait beyond the deadline to fail, got %v", err)
+	}
+	expectWait(100 * time.Millisecond)
+}
+
+func TestDistributedRateLimiter_SharedBudget(t *testing.T) {
+
+	const url = "https://developer.api.autodesk.com/data/v1/projects/p/folders/f"
+	const requests = 10
+
+	store := NewMemoryBucketStore()
+
+	// each replica has its own RateLimiter, as a separate process would, with a limit of 100 requests per second
+	replica := func() *DistributedRateLimiter {
+		endpoints := ApiEndpoints{"GET": {apiUrlRegexp(`projects/{project_id}/folders/{folder_id}$`): limitPerMinute(6000)}}
+		return &DistributedRateLimiter{
+			Store:  store,
+			Limits: NewRateLimiter(&endpoints, &DefaultOSSLimiter, limitPerMinute(50)),
+			Prefix: "app:",
+		}
+	}
+	replicas := []*DistributedRateLimiter{replica(), replica()}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, limiter := range replicas {
+		for i := 0; i < requests; i++ {
+			wg.Add(1)
+			go func(limiter *DistributedRateLimiter) {
+				defer wg.Done()
+				if err := limiter.Wait(context.Background(), "GET", url); err != nil {
+					t.Error(err.Error())
+				}
+			}(limiter)
+		}
+	}
+	wg.Wait()
+
+	// 2 replicas sending 10 requests each share one budget: the last one waits for 19 refills of 10ms
+	if elapsed := time.Since(start); elapsed < 180*time.Millisecond {
+		t.Errorf("Expected the replicas to share one budget, all the requests were allowed in %s", elapsed)
+	}
+
+	t.Run("Deadline", func(t *testing.T) {
+		// another replica reserves a second of requests
+		key := "app:GET " + apiUrlRegexp(`projects/{project_id}/folders/{folder_id}$`).String()
+		for i := 0; i < 100; i++ {
+			store.Take(context.Background(), key, rate.Limit(100), 1)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if err := replicas[0].Wait(ctx, "GET", url); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected a wait beyond the deadline to fail, got %v", err)
+		}
+	})
+}
+
+// recordingBucketStore records the limits of the buckets it is asked for
+type recordingBucketStore struct {
+	limits []rate.Limit
+}
+
+func (s *recordingBucketStore) Take(ctx context.Context, key string, limit rate.Limit, burst int) (time.Duration, error) {
+	s.limits = append(s.limits, limit)
+	return 0, nil
+}
+
+func TestDistributedRateLimiter_BaseLimit(t *testing.T) {
+
+	const url = "https://developer.api.autodesk.com/data/v1/projects/p/folders/f"
+
+	endpoints := ApiEndpoints{"GET": {apiUrlRegexp(`projects/{project_id}/folders/{folder_id}$`): limitPerMinute(6000)}}
+	store := &recordingBucketStore{}
+	limiter := &DistributedRateLimiter{
+		Store:  store,
+		Limits: NewRateLimiter(&endpoints, &DefaultOSSLimiter, limitPerMinute(50)),
+	}
+
+	// the process is throttled, which halves its local limit
+	req, _ := http.NewRequest("GET", url, nil)
+	limiter.ObserveResponse(req, &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"0"}}})
+	if state := limiter.Limits.State(); len(state) != 1 || state[0].Limit != 50 {
+		t.Fatalf("Expected the local limit to be lowered, got %+v", state)
+	}
+
+	if err := limiter.Wait(context.Background(), "GET", url); err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(store.limits) != 1 || store.limits[0] != 100 {
+		t.Errorf("Expected the shared bucket to keep the configured limit, got %v", store.limits)
+	}
+}
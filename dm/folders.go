@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	forge "github.com/outer-labs/forge-api-go-client"
 	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
@@ -36,7 +37,7 @@ func (api FolderAPI) GetFolderDetails(ctx context.Context, projectKey, folderKey
 
 	path := api.Host + api.FolderAPIPath
 
-	return getFolderDetails(ctx, api.RateLimiter, path, projectKey, folderKey, bearer.AccessToken)
+	return getFolderDetails(ctx, api.Client, api.RateLimiter, path, projectKey, folderKey, bearer.AccessToken)
 }
 
 func (api FolderAPI) GetFolderContents(ctx context.Context, projectKey, folderKey string) (result ForgeResponseArray, err error) {
@@ -46,16 +47,14 @@ func (api FolderAPI) GetFolderContents(ctx context.Context, projectKey, folderKe
 	}
 	path := api.Host + api.FolderAPIPath
 
-	return getFolderContents(ctx, api.RateLimiter, path, projectKey, folderKey, bearer.AccessToken)
+	return getFolderContents(ctx, api.Client, api.RateLimiter, path, projectKey, folderKey, bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
-func getFolderDetails(ctx context.Context, limiter HttpRequestLimiter, path, projectKey, folderKey, token string) (result ForgeResponseObject, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func getFolderDetails(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, projectKey, folderKey, token string) (result ForgeResponseObject, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+projectKey+"/folders/"+folderKey,
 		nil,
 	)
@@ -64,7 +63,7 @@ func getFolderDetails(ctx context.Context, limiter HttpRequestLimiter, path, pro
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -72,8 +71,7 @@ func getFolderDetails(ctx context.Context, limiter HttpRequestLimiter, path, pro
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -82,11 +80,10 @@ func getFolderDetails(ctx context.Context, limiter HttpRequestLimiter, path, pro
 	return
 }
 
-func getFolderContents(ctx context.Context, limiter HttpRequestLimiter, path, projectKey, folderKey, token string) (result ForgeResponseArray, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(
+func getFolderContents(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, projectKey, folderKey, token string) (result ForgeResponseArray, err error) {
+	req, err := forge.NewRequest(
 		ctx,
+		limiter,
 		"GET",
 		path+"/"+projectKey+"/folders/"+folderKey+"/contents",
 		nil,
@@ -97,7 +94,7 @@ func getFolderContents(ctx context.Context, limiter HttpRequestLimiter, path, pr
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -105,8 +102,7 @@ func getFolderContents(ctx context.Context, limiter HttpRequestLimiter, path, pr
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -33,7 +33,7 @@ func (a FolderAPI3L) GetFolderDetailsThreeLegged(ctx context.Context, projectKey
 	}
 
 	path := a.Auth.Host + a.FolderAPIPath
-	return getFolderDetails(ctx, a.RateLimiter, path, projectKey, folderKey, a.Token.Bearer().AccessToken)
+	return getFolderDetails(ctx, a.Auth.Client, a.RateLimiter, path, projectKey, folderKey, a.Token.Bearer().AccessToken)
 }
 
 func (a FolderAPI3L) GetFolderContentsThreeLegged(ctx context.Context, projectKey, folderKey string) (result ForgeResponseArray, err error) {
@@ -42,7 +42,7 @@ func (a FolderAPI3L) GetFolderContentsThreeLegged(ctx context.Context, projectKe
 	}
 
 	path := a.Auth.Host + a.FolderAPIPath
-	return getFolderContents(ctx, a.RateLimiter, path, projectKey, folderKey, a.Token.Bearer().AccessToken)
+	return getFolderContents(ctx, a.Auth.Client, a.RateLimiter, path, projectKey, folderKey, a.Token.Bearer().AccessToken)
 }
 
 func (a FolderAPI3L) GetItemDetailsThreeLegged(ctx context.Context, projectKey, itemKey string) (result ForgeResponseObject, err error) {
@@ -52,5 +52,5 @@ func (a FolderAPI3L) GetItemDetailsThreeLegged(ctx context.Context, projectKey,
 
 	path := a.Auth.Host + a.FolderAPIPath
 
-	return getItemDetails(ctx, a.RateLimiter, path, projectKey, itemKey, a.Token.Bearer().AccessToken)
+	return getItemDetails(ctx, a.Auth.Client, a.RateLimiter, path, projectKey, itemKey, a.Token.Bearer().AccessToken)
 }
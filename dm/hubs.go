@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	forge "github.com/outer-labs/forge-api-go-client"
 	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
@@ -34,7 +35,7 @@ func (api HubAPI) GetHubs(ctx context.Context) (result ForgeResponseArray, err e
 	}
 	path := api.Host + api.HubAPIPath
 
-	return getHubs(ctx, api.RateLimiter, path, bearer.AccessToken)
+	return getHubs(ctx, api.Client, api.RateLimiter, path, bearer.AccessToken)
 }
 
 func (api HubAPI) GetHubDetails(ctx context.Context, hubKey string) (result ForgeResponseObject, err error) {
@@ -44,24 +45,22 @@ func (api HubAPI) GetHubDetails(ctx context.Context, hubKey string) (result Forg
 	}
 	path := api.Host + api.HubAPIPath
 
-	return getHubDetails(ctx, api.RateLimiter, path, hubKey, bearer.AccessToken)
+	return getHubDetails(ctx, api.Client, api.RateLimiter, path, hubKey, bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
 
-func getHubs(ctx context.Context, limiter HttpRequestLimiter, path, token string) (result ForgeResponseArray, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET", path, nil)
+func getHubs(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, token string) (result ForgeResponseArray, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET", path, nil)
 	if err != nil {
 		return
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -69,8 +68,7 @@ func getHubs(ctx context.Context, limiter HttpRequestLimiter, path, token string
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -79,17 +77,15 @@ func getHubs(ctx context.Context, limiter HttpRequestLimiter, path, token string
 	return
 }
 
-func getHubDetails(ctx context.Context, limiter HttpRequestLimiter, path, hubKey, token string) (result ForgeResponseObject, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET", path+"/"+hubKey, nil)
+func getHubDetails(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, hubKey, token string) (result ForgeResponseObject, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET", path+"/"+hubKey, nil)
 	if err != nil {
 		return
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -97,8 +93,7 @@ func getHubDetails(ctx context.Context, limiter HttpRequestLimiter, path, hubKey
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
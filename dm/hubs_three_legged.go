@@ -33,7 +33,7 @@ func (a *HubAPI3L) GetHubsThreeLegged(ctx context.Context) (result ForgeResponse
 	}
 
 	path := a.Auth.Host + a.HubAPIPath
-	return getHubs(ctx, a.RateLimiter, path, a.Token.Bearer().AccessToken)
+	return getHubs(ctx, a.Auth.Client, a.RateLimiter, path, a.Token.Bearer().AccessToken)
 }
 
 func (a *HubAPI3L) GetHubDetailsThreeLegged(ctx context.Context, hubKey string) (result ForgeResponseObject, err error) {
@@ -42,7 +42,7 @@ func (a *HubAPI3L) GetHubDetailsThreeLegged(ctx context.Context, hubKey string)
 	}
 
 	path := a.Auth.Host + a.HubAPIPath
-	return getHubDetails(ctx, a.RateLimiter, path, hubKey, a.Token.Bearer().AccessToken)
+	return getHubDetails(ctx, a.Auth.Client, a.RateLimiter, path, hubKey, a.Token.Bearer().AccessToken)
 }
 
 func (a *HubAPI3L) ListProjectsThreeLegged(ctx context.Context, hubKey string) (result ForgeResponseArray, err error) {
@@ -51,7 +51,7 @@ func (a *HubAPI3L) ListProjectsThreeLegged(ctx context.Context, hubKey string) (
 	}
 
 	path := a.Auth.Host + a.HubAPIPath
-	return listProjects(ctx, a.RateLimiter, path, hubKey, "", "", "", "", a.Token.Bearer().AccessToken)
+	return listProjects(ctx, a.Auth.Client, a.RateLimiter, path, hubKey, "", "", "", "", a.Token.Bearer().AccessToken)
 }
 
 func (a *HubAPI3L) GetProjectDetailsThreeLegged(ctx context.Context, hubKey, projectKey string) (result ForgeResponseObject, err error) {
@@ -60,7 +60,7 @@ func (a *HubAPI3L) GetProjectDetailsThreeLegged(ctx context.Context, hubKey, pro
 	}
 
 	path := a.Auth.Host + a.HubAPIPath
-	return getProjectDetails(ctx, a.RateLimiter, path, hubKey, projectKey, a.Token.Bearer().AccessToken)
+	return getProjectDetails(ctx, a.Auth.Client, a.RateLimiter, path, hubKey, projectKey, a.Token.Bearer().AccessToken)
 }
 
 func (a *HubAPI3L) GetTopFoldersThreeLegged(ctx context.Context, hubKey, projectKey string) (result ForgeResponseArray, err error) {
@@ -69,5 +69,5 @@ func (a *HubAPI3L) GetTopFoldersThreeLegged(ctx context.Context, hubKey, project
 	}
 
 	path := a.Auth.Host + a.HubAPIPath
-	return getTopFolders(ctx, a.RateLimiter, path, hubKey, projectKey, a.Token.Bearer().AccessToken)
+	return getTopFolders(ctx, a.Auth.Client, a.RateLimiter, path, hubKey, projectKey, a.Token.Bearer().AccessToken)
 }
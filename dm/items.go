@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
 // ListBuckets returns a list of all buckets created or associated with Forge secrets used for token creation
@@ -18,7 +20,7 @@ func (api FolderAPI) GetItemDetails(ctx context.Context, projectKey, itemKey str
 
 	path := api.Host + api.FolderAPIPath
 
-	return getItemDetails(ctx, api.RateLimiter, path, projectKey, itemKey, bearer.AccessToken)
+	return getItemDetails(ctx, api.Client, api.RateLimiter, path, projectKey, itemKey, bearer.AccessToken)
 }
 
 func (api FolderAPI) GetItemTip(ctx context.Context, projectKey, itemKey string) (result ForgeResponseObject, err error) {
@@ -32,7 +34,7 @@ func (api FolderAPI) GetItemTip(ctx context.Context, projectKey, itemKey string)
 
 	path := api.Host + api.FolderAPIPath
 
-	return getItemDetails(ctx, api.RateLimiter, path, projectKey, itemKey, bearer.AccessToken)
+	return getItemDetails(ctx, api.Client, api.RateLimiter, path, projectKey, itemKey, bearer.AccessToken)
 }
 
 func (api FolderAPI) GetItemVersions(ctx context.Context, projectKey, itemKey string) (result ForgeResponseArray, err error) {
@@ -46,22 +48,20 @@ func (api FolderAPI) GetItemVersions(ctx context.Context, projectKey, itemKey st
 
 	path := api.Host + api.FolderAPIPath
 
-	return getItemVersions(ctx, api.RateLimiter, path, projectKey, itemKey, "", "", "", "", "", "", bearer.AccessToken)
+	return getItemVersions(ctx, api.Client, api.RateLimiter, path, projectKey, itemKey, "", "", "", "", "", "", bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
-func getItemDetails(ctx context.Context, limiter HttpRequestLimiter, path, projectKey, itemKey, token string) (result ForgeResponseObject, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET", path+"/"+projectKey+"/items/"+itemKey, nil)
+func getItemDetails(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, projectKey, itemKey, token string) (result ForgeResponseObject, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET", path+"/"+projectKey+"/items/"+itemKey, nil)
 	if err != nil {
 		return
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -69,8 +69,7 @@ func getItemDetails(ctx context.Context, limiter HttpRequestLimiter, path, proje
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -79,16 +78,14 @@ func getItemDetails(ctx context.Context, limiter HttpRequestLimiter, path, proje
 	return
 }
 
-func getItemTip(ctx context.Context, limiter HttpRequestLimiter, path, projectKey, itemKey, token string) (result ForgeResponseObject, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET", path+"/"+projectKey+"/items/"+itemKey+"/tip", nil)
+func getItemTip(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, projectKey, itemKey, token string) (result ForgeResponseObject, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET", path+"/"+projectKey+"/items/"+itemKey+"/tip", nil)
 	if err != nil {
 		return
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -96,8 +93,7 @@ func getItemTip(ctx context.Context, limiter HttpRequestLimiter, path, projectKe
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -106,10 +102,8 @@ func getItemTip(ctx context.Context, limiter HttpRequestLimiter, path, projectKe
 	return
 }
 
-func getItemVersions(ctx context.Context, limiter HttpRequestLimiter, path, projectKey, itemKey, refType, id, extension, versionNumber, page, limit, token string) (result ForgeResponseArray, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET", path+"/"+projectKey+"/items/"+itemKey+"/versions", nil)
+func getItemVersions(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, projectKey, itemKey, refType, id, extension, versionNumber, page, limit, token string) (result ForgeResponseArray, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET", path+"/"+projectKey+"/items/"+itemKey+"/versions", nil)
 	if err != nil {
 		return
 	}
@@ -137,7 +131,7 @@ func getItemVersions(ctx context.Context, limiter HttpRequestLimiter, path, proj
 	req.URL.RawQuery = params.Encode()
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -145,8 +139,7 @@ func getItemVersions(ctx context.Context, limiter HttpRequestLimiter, path, proj
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -6,12 +6,14 @@ import (
 	"io"
 	"net/http"
 	"regexp"
+	"sync"
 	"time"
 
 	"golang.org/x/time/rate"
 )
 
-var DefaultRateLimiter = NewRateLimiter(&DefaultDataManagementLimits, &DefaultOSSLimiter, DefaultFallbackLimiter)
+var DefaultRateLimiter = NewRateLimiter(&DefaultDataManagementLimits, &DefaultOSSLimiter, DefaultFallbackLimiter).
+	WithEndpoints(&DefaultModelDerivativeLimits, &DefaultReCapLimits, &DefaultAuthenticationLimits)
 
 type ApiEndpoints map[string]map[*regexp.Regexp]*rate.Limiter
 
@@ -24,6 +26,16 @@ type RateLimiter struct {
 	dm       *ApiEndpoints
 	oss      *OSSLimiter
 	fallback *rate.Limiter
+	// others holds the tables of the services other than Data Management, see WithEndpoints
+	others []*ApiEndpoints
+	// tables guards dm, oss, fallback, others and owned, which Reload replaces
+	tables sync.RWMutex
+	// owned holds the limiters created from a LimitsConfig, which Reload may update in place
+	owned map[*rate.Limiter]bool
+
+	// mutex guards adjusted, which holds the state of the limiters adjusted by ObserveResponse
+	mutex    sync.Mutex
+	adjusted map[*rate.Limiter]*LimitState
 }
 
 var DefaultDataManagementLimits = ApiEndpoints{
@@ -101,6 +113,52 @@ var DefaultDataManagementLimits = ApiEndpoints{
 	},
 }
 
+// DefaultModelDerivativeLimits holds the limits of the Model Derivative API, used by the md package
+var DefaultModelDerivativeLimits = ApiEndpoints{
+	"GET": {
+		mdUrlRegexp(`{urn}/manifest$`):                          limitPerMinute(300),
+		mdUrlRegexp(`{urn}/metadata$`):                          limitPerMinute(300),
+		mdUrlRegexp(`{urn}/metadata/{guid}$`):                   limitPerMinute(60),
+		mdUrlRegexp(`{urn}/metadata/{guid}/properties(\?.*)?$`): limitPerMinute(60),
+		mdUrlRegexp(`{urn}/thumbnail(\?.*)?$`):                  limitPerMinute(50),
+	},
+	"POST": {
+		mdUrlRegexp(`job$`): limitPerMinute(50),
+	},
+	"DELETE": {
+		mdUrlRegexp(`{urn}/manifest$`): limitPerMinute(60),
+	},
+}
+
+// DefaultReCapLimits holds the limits of the Reality Capture API, used by the recap package
+var DefaultReCapLimits = ApiEndpoints{
+	"GET": {
+		recapUrlRegexp(`photoscene/{photoscene_id}/progress$`): limitPerMinute(300),
+		recapUrlRegexp(`photoscene/{photoscene_id}(\?.*)?$`):   limitPerMinute(50),
+	},
+	"POST": {
+		recapUrlRegexp(`photoscene$`):                        limitPerMinute(50),
+		recapUrlRegexp(`file$`):                              limitPerMinute(300),
+		recapUrlRegexp(`photoscene/{photoscene_id}$`):        limitPerMinute(50),
+		recapUrlRegexp(`photoscene/{photoscene_id}/cancel$`): limitPerMinute(50),
+	},
+	"DELETE": {
+		recapUrlRegexp(`photoscene/{photoscene_id}$`): limitPerMinute(50),
+	},
+}
+
+// DefaultAuthenticationLimits holds the limits of the Authentication API, used by the oauth package
+var DefaultAuthenticationLimits = ApiEndpoints{
+	"GET": {
+		authUrlRegexp(`keys$`):         limitPerMinute(100),
+		profileUrlRegexp(`users/@me$`): limitPerMinute(500),
+	},
+	"POST": {
+		authUrlRegexp(`(authenticate|gettoken|refreshtoken|token)$`): limitPerMinute(500),
+		authUrlRegexp(`(revoke|introspect)$`):                        limitPerMinute(100),
+	},
+}
+
 var DefaultOSSLimiter = OSSLimiter{
 	matcher: regexp.MustCompile(`^https?://developer.api.autodesk.com/oss/v2`),
 	limiter: limitPerMinute(1000),
@@ -116,43 +174,118 @@ func NewRateLimiter(endpoints *ApiEndpoints, oss *OSSLimiter, fallback *rate.Lim
 	}
 }
 
+// WithEndpoints adds the tables of other services, such as DefaultModelDerivativeLimits, and returns the RateLimiter.
+// The tables are searched in order after the Data Management one, the fallback limiter applying to the requests
+// matching none of them.
+func (r *RateLimiter) WithEndpoints(tables ...*ApiEndpoints) *RateLimiter {
+	r.tables.Lock()
+	defer r.tables.Unlock()
+
+	r.others = append(r.others, tables...)
+	return r
+}
+
 func (r *RateLimiter) HttpRequest(
 	ctx context.Context,
 	method string,
 	url string,
 	body io.Reader,
 ) (*http.Request, error) {
-	if err := r.limiter(method, url).Wait(ctx); err != nil {
+	if err := r.Wait(ctx, method, url); err != nil {
 		return nil, fmt.Errorf("rate limit wait: %w", err)
 	}
 
-	return http.NewRequest(method, url, body)
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+// Wait blocks until the limit of the endpoint matching method and url allows a request, so that the
+// RateLimiter can be used as a forge.Limiter by the RateLimit middleware.
+// If the endpoint was paused after being throttled, Wait first waits for the end of the pause.
+func (r *RateLimiter) Wait(ctx context.Context, method string, url string) error {
+	limiter := r.limiter(method, url)
+	if err := r.waitPause(ctx, limiter); err != nil {
+		return err
+	}
+
+	return limiter.Wait(ctx)
 }
 
 func (r *RateLimiter) limiter(method, url string) *rate.Limiter {
+	limiter, _, _ := r.endpoint(method, url)
+	return limiter
+}
+
+// endpoint returns the limiter of the endpoint matching method and url, with the method and pattern
+// it was found with
+func (r *RateLimiter) endpoint(method, url string) (*rate.Limiter, string, string) {
+	r.tables.RLock()
+	defer r.tables.RUnlock()
+
 	if r.oss.matcher.MatchString(url) {
-		return r.oss.limiter
+		return r.oss.limiter, "", "oss"
 	}
 
-	set, ok := (*r.dm)[method]
-	if !ok {
-		return r.fallback
+	for _, endpoints := range append([]*ApiEndpoints{r.dm}, r.others...) {
+		if limiter, pattern := endpoints.match(method, url); limiter != nil {
+			return limiter, method, pattern
+		}
 	}
 
-	for k, v := range set {
+	return r.fallback, "", "fallback"
+}
+
+// match returns the limiter and the pattern of the endpoint matching method and url, or nil
+func (e *ApiEndpoints) match(method, url string) (*rate.Limiter, string) {
+	if e == nil {
+		return nil, ""
+	}
+
+	for k, v := range (*e)[method] {
 		if k.MatchString(url) {
-			return v
+			return v, k.String()
 		}
 	}
 
-	return r.fallback
+	return nil, ""
 }
 
-var variableToRegexp = regexp.MustCompile("{.+}")
+// variableToRegexp matches the variables of the endpoints, each one replaced by a single path segment
+// so that e.g. a folder and its contents have different limits.
+// The variables used to be replaced greedily, from the first one to the last, so that the Data Management endpoints
+// sharing their first segment overlapped, e.g. all the projects/{project_id}/... ones became projects/.+, and a
+// request was limited by any of them.
+var variableToRegexp = regexp.MustCompile("{[^}]+}")
+
+// The URLs of the endpoints of each service start with these patterns
+const (
+	dataManagementUrl  = "^https?://developer.api.autodesk.com/data/v(1|2)/"
+	modelDerivativeUrl = "^https?://developer.api.autodesk.com/modelderivative/v2/(regions/[^/]+/)?designdata/"
+	reCapUrl           = "^https?://developer.api.autodesk.com/photo-to-3d/v1/"
+	authenticationUrl  = "^https?://developer.api.autodesk.com/authentication/v(1|2)/"
+	userProfileUrl     = "^https?://developer.api.autodesk.com/userprofile/v1/"
+)
 
 func apiUrlRegexp(stub string) *regexp.Regexp {
-	replaced := variableToRegexp.ReplaceAllString(stub, ".+")
-	return regexp.MustCompile("^https?://developer.api.autodesk.com/data/v(1|2)/" + replaced)
+	replaced := variableToRegexp.ReplaceAllString(stub, "[^/]+")
+	return regexp.MustCompile(dataManagementUrl + replaced)
+}
+
+func mdUrlRegexp(stub string) *regexp.Regexp {
+	replaced := variableToRegexp.ReplaceAllString(stub, "[^/]+")
+	return regexp.MustCompile(modelDerivativeUrl + replaced)
+}
+
+func recapUrlRegexp(stub string) *regexp.Regexp {
+	replaced := variableToRegexp.ReplaceAllString(stub, "[^/]+")
+	return regexp.MustCompile(reCapUrl + replaced)
+}
+
+func authUrlRegexp(stub string) *regexp.Regexp {
+	return regexp.MustCompile(authenticationUrl + stub)
+}
+
+func profileUrlRegexp(stub string) *regexp.Regexp {
+	return regexp.MustCompile(userProfileUrl + stub)
 }
 
 func limitPerMinute(r time.Duration) *rate.Limiter {
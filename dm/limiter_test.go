@@ -0,0 +1,107 @@
+package dm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/time/rate"
+)
+
+// entry returns the limiter of the endpoint of table whose pattern ends with suffix
+func entry(t *testing.T, table ApiEndpoints, method, suffix string) *rate.Limiter {
+	for matcher, limiter := range table[method] {
+		if strings.HasSuffix(matcher.String(), suffix) {
+			return limiter
+		}
+	}
+	t.Fatalf("No %s endpoint ending with %s", method, suffix)
+
+	return nil
+}
+
+func TestRateLimiter_limiter(t *testing.T) {
+
+	const host = "https://developer.api.autodesk.com"
+
+	tests := []struct {
+		method   string
+		url      string
+		expected *rate.Limiter
+	}{
+		{"GET", host + "/data/v1/projects/p/folders/f",
+			entry(t, DefaultDataManagementLimits, "GET", `projects/[^/]+/folders/[^/]+$`)},
+		{"GET", host + "/data/v1/projects/p/folders/f/contents",
+			entry(t, DefaultDataManagementLimits, "GET", `projects/[^/]+/folders/[^/]+/contents$`)},
+		{"POST", host + "/modelderivative/v2/designdata/job",
+			entry(t, DefaultModelDerivativeLimits, "POST", `job$`)},
+		{"GET", host + "/modelderivative/v2/designdata/urn/metadata/guid/properties?forceget=true",
+			entry(t, DefaultModelDerivativeLimits, "GET", `[^/]+/metadata/[^/]+/properties(\?.*)?$`)},
+		{"GET", host + "/modelderivative/v2/designdata/urn/metadata/guid",
+			entry(t, DefaultModelDerivativeLimits, "GET", `[^/]+/metadata/[^/]+$`)},
+		{"GET", host + "/photo-to-3d/v1/photoscene/scene/progress",
+			entry(t, DefaultReCapLimits, "GET", `photoscene/[^/]+/progress$`)},
+		{"POST", host + "/authentication/v2/token",
+			entry(t, DefaultAuthenticationLimits, "POST", `(authenticate|gettoken|refreshtoken|token)$`)},
+		{"GET", host + "/userprofile/v1/users/@me",
+			entry(t, DefaultAuthenticationLimits, "GET", `users/@me$`)},
+		{"GET", host + "/unknown/v1/resource", DefaultFallbackLimiter},
+	}
+
+	for _, test := range tests {
+		if got := DefaultRateLimiter.limiter(test.method, test.url); got != test.expected {
+			t.Errorf("Unexpected limiter for %s %s", test.method, test.url)
+		}
+	}
+}
+
+// Each variable of the Data Management endpoints matches a single segment, so that the endpoints sharing
+// their first segments do not overlap
+func TestDefaultDataManagementLimits_NoOverlap(t *testing.T) {
+
+	const base = "https://developer.api.autodesk.com/data/v1/"
+
+	tests := []struct {
+		method string
+		path   string
+		limit  rate.Limit
+	}{
+		{"GET", "projects/p/items/i", 5},
+		{"GET", "projects/p/items/i/parent", 50.0 / 60},
+		{"GET", "projects/p/items/i/versions", 800.0 / 60},
+		{"GET", "projects/p/versions/v/downloadFormats", 50.0 / 60},
+		{"GET", "hubs/h/projects/p/topFolders", 5},
+		{"PATCH", "projects/p/versions/v/relationships/links/l", 50.0 / 60},
+	}
+
+	for _, test := range tests {
+		var matching []string
+		for matcher := range DefaultDataManagementLimits[test.method] {
+			if matcher.MatchString(base + test.path) {
+				matching = append(matching, matcher.String())
+			}
+		}
+		if len(matching) != 1 {
+			t.Errorf("Expected %s %s to match a single endpoint, got %q", test.method, test.path, matching)
+			continue
+		}
+
+		limit := DefaultRateLimiter.limiter(test.method, base+test.path).Limit()
+		if diff := limit - test.limit; diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("Expected %s %s to be limited to %v/s, got %v/s", test.method, test.path, test.limit, limit)
+		}
+	}
+}
+
+func TestRateLimiter_HttpRequest(t *testing.T) {
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+
+	req, err := DefaultRateLimiter.HttpRequest(ctx, "GET", "https://developer.api.autodesk.com/oss/v2/buckets", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if req.Context().Value(key{}) != "value" {
+		t.Error("Expected the request to carry the context, so that cancelling it stops the request")
+	}
+}
@@ -0,0 +1,416 @@
+package dm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gopkg.in/yaml.v2"
+)
+
+// LimitsConfig describes the rate limits of a RateLimiter, so that they can be changed without a new release
+// when Autodesk changes the quotas. It is loaded from a YAML or JSON file, e.g.
+//
+//	dataManagement:
+//	  GET:
+//	    hubs/{hub_id}/projects: {perMinute: 50}
+//	    projects/{project_id}/items/{item_id}/versions: {perMinute: 800, burst: 5}
+//	oss: {perMinute: 1000}
+//	fallback: {perMinute: 50}
+//
+// A service given in the file has only the endpoints listed, the requests to its other endpoints falling back to
+// the fallback limit. The services which are not given keep their default table, such as DefaultModelDerivativeLimits.
+type LimitsConfig struct {
+	DataManagement  EndpointsConfig `json:"dataManagement,omitempty" yaml:"dataManagement,omitempty"`
+	ModelDerivative EndpointsConfig `json:"modelDerivative,omitempty" yaml:"modelDerivative,omitempty"`
+	ReCap           EndpointsConfig `json:"reCap,omitempty" yaml:"reCap,omitempty"`
+	Authentication  EndpointsConfig `json:"authentication,omitempty" yaml:"authentication,omitempty"`
+	OSS             *LimitConfig    `json:"oss,omitempty" yaml:"oss,omitempty"`
+	Fallback        *LimitConfig    `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+}
+
+// EndpointsConfig maps the HTTP methods to the path templates of the endpoints of a service and their limits.
+//
+// The templates are relative to the base URL of the service, e.g. "hubs/{hub_id}/projects" for Data Management,
+// unless they are absolute URLs. Each {variable} matches a single path segment, and the URLs may end with a query
+// string.
+type EndpointsConfig map[string]map[string]LimitConfig
+
+// LimitConfig is the limit of an endpoint
+type LimitConfig struct {
+	PerMinute float64 `json:"perMinute" yaml:"perMinute"`
+	// Burst is the number of requests that can be sent at once, 1 if not set
+	Burst int `json:"burst,omitempty" yaml:"burst,omitempty"`
+}
+
+// LimitsConfigError lists the problems found in a LimitsConfig
+type LimitsConfigError struct {
+	Problems []string
+}
+
+func (e *LimitsConfigError) Error() string {
+	return "invalid rate limits: " + strings.Join(e.Problems, "; ")
+}
+
+// LoadLimitsConfig reads and validates the limits of the YAML or JSON file at path
+func LoadLimitsConfig(path string) (*LimitsConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseLimitsConfig(data)
+}
+
+// ParseLimitsConfig parses and validates limits given in YAML or JSON, which is valid YAML.
+// Unknown fields and empty documents are rejected, so that an empty file does not reset every limit to its default.
+func ParseLimitsConfig(data []byte) (*LimitsConfig, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("could not parse the rate limits: empty document")
+	}
+
+	config := &LimitsConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("could not parse the rate limits: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// Validate returns a *LimitsConfigError listing the unknown methods, malformed patterns and invalid limits of c
+func (c *LimitsConfig) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+// NewRateLimiterFromConfig returns a RateLimiter with the limits of config
+func NewRateLimiterFromConfig(config *LimitsConfig) (*RateLimiter, error) {
+	tables, err := config.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	oss := &OSSLimiter{matcher: DefaultOSSLimiter.matcher, limiter: tables.oss}
+	r := NewRateLimiter(tables.dm, oss, tables.fallback).WithEndpoints(tables.others...)
+	r.owned = tables.limiters()
+
+	return r, nil
+}
+
+// Reload replaces the limits of r with those of config, leaving them unchanged if config is invalid.
+//
+// The limiters of the endpoints found in both the current and the new limits are updated in place, so that the
+// requests waiting for them are not dropped, and an endpoint lowered after a 429 response is restored up to its
+// new limit. The requests waiting for a removed endpoint complete with its former limit.
+//
+// Only the limiters created from a LimitsConfig are updated in place: the ones of the default tables, which
+// the other RateLimiters may share, are replaced by new ones instead.
+func (r *RateLimiter) Reload(config *LimitsConfig) error {
+	tables, err := config.compile()
+	if err != nil {
+		return err
+	}
+
+	r.tables.Lock()
+	defer r.tables.Unlock()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	previous := make(map[string]*rate.Limiter)
+	for _, endpoints := range append([]*ApiEndpoints{r.dm}, r.others...) {
+		if endpoints == nil {
+			continue
+		}
+		for method, limiters := range *endpoints {
+			for matcher, limiter := range limiters {
+				previous[method+" "+matcher.String()] = limiter
+			}
+		}
+	}
+
+	kept := make(map[*rate.Limiter]bool)
+	reuse := func(endpoints *ApiEndpoints) *ApiEndpoints {
+		reused := make(ApiEndpoints, len(*endpoints))
+		for method, limiters := range *endpoints {
+			reused[method] = make(map[*regexp.Regexp]*rate.Limiter, len(limiters))
+			for matcher, limiter := range limiters {
+				if current, ok := previous[method+" "+matcher.String()]; ok && r.owned[current] {
+					r.update(current, limiter)
+					limiter = current
+				}
+				reused[method][matcher] = limiter
+				kept[limiter] = true
+			}
+		}
+		return &reused
+	}
+
+	r.dm = reuse(tables.dm)
+	r.others = make([]*ApiEndpoints, len(tables.others))
+	for i, endpoints := range tables.others {
+		r.others[i] = reuse(endpoints)
+	}
+
+	if r.oss != nil && r.owned[r.oss.limiter] {
+		r.update(r.oss.limiter, tables.oss)
+	} else {
+		matcher := DefaultOSSLimiter.matcher
+		if r.oss != nil {
+			matcher = r.oss.matcher
+		}
+		r.oss = &OSSLimiter{matcher: matcher, limiter: tables.oss}
+	}
+	if r.fallback != nil && r.owned[r.fallback] {
+		r.update(r.fallback, tables.fallback)
+	} else {
+		r.fallback = tables.fallback
+	}
+	kept[r.oss.limiter] = true
+	kept[r.fallback] = true
+	r.owned = kept
+
+	for limiter := range r.adjusted {
+		if !kept[limiter] {
+			delete(r.adjusted, limiter)
+		}
+	}
+
+	return nil
+}
+
+// WatchLimitsFile reloads the limits of r from the YAML or JSON file at path when its content changes, checking it
+// every interval until ctx is done. The file is loaded on the first check. The errors, including invalid limits
+// which leave the current ones unchanged, are passed to onError if not nil.
+//
+// The file must be replaced by renaming a complete file over it, not rewritten in place: a check reading it while it
+// is written could find a partial document, and a truncated YAML document can still be valid.
+//
+//	go limiter.WatchLimitsFile(ctx, "limits.yaml", time.Minute, func(err error) { log.Println(err) })
+func (r *RateLimiter) WatchLimitsFile(ctx context.Context, path string, interval time.Duration, onError func(error)) {
+	var loaded []byte
+	check := func() {
+		data, err := ioutil.ReadFile(path)
+		if err == nil && loaded != nil && bytes.Equal(data, loaded) {
+			return
+		}
+		if err == nil {
+			// an invalid content is reported once, not on every check
+			loaded = data
+
+			var config *LimitsConfig
+			if config, err = ParseLimitsConfig(data); err == nil {
+				err = r.Reload(config)
+			}
+		}
+		if err != nil && onError != nil {
+			onError(fmt.Errorf("could not reload the rate limits from %s: %w", path, err))
+		}
+	}
+
+	check()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// update sets the limit and burst of limiter to those of configured, r.mutex being held.
+// A limiter lowered after a 429 response keeps its lower limit, restored up to the new one by the next successes.
+func (r *RateLimiter) update(limiter, configured *rate.Limiter) {
+	limit := configured.Limit()
+	if state, ok := r.adjusted[limiter]; ok {
+		state.BaseLimit = limit
+		state.Limit = minLimit(state.Limit, limit)
+		limit = state.Limit
+	}
+	limiter.SetLimit(limit)
+	limiter.SetBurst(configured.Burst())
+}
+
+// limiterTables are the limiters compiled from a LimitsConfig
+type limiterTables struct {
+	dm       *ApiEndpoints
+	others   []*ApiEndpoints
+	oss      *rate.Limiter
+	fallback *rate.Limiter
+}
+
+// limiters returns the set of the limiters of t
+func (t *limiterTables) limiters() map[*rate.Limiter]bool {
+	limiters := map[*rate.Limiter]bool{t.oss: true, t.fallback: true}
+	for _, endpoints := range append([]*ApiEndpoints{t.dm}, t.others...) {
+		for _, table := range *endpoints {
+			for _, limiter := range table {
+				limiters[limiter] = true
+			}
+		}
+	}
+
+	return limiters
+}
+
+var knownMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+// compile returns the limiters of c, or a *LimitsConfigError
+func (c *LimitsConfig) compile() (*limiterTables, error) {
+	var problems []string
+
+	limiter := func(name string, limit LimitConfig) *rate.Limiter {
+		if limit.PerMinute <= 0 {
+			problems = append(problems, fmt.Sprintf("%s: perMinute must be positive, got %v", name, limit.PerMinute))
+			return nil
+		}
+		if limit.Burst < 0 {
+			problems = append(problems, fmt.Sprintf("%s: burst cannot be negative, got %d", name, limit.Burst))
+			return nil
+		}
+		burst := limit.Burst
+		if burst == 0 {
+			burst = 1
+		}
+
+		return rate.NewLimiter(rate.Limit(limit.PerMinute/60), burst)
+	}
+
+	table := func(name, base string, endpoints EndpointsConfig, defaults *ApiEndpoints) *ApiEndpoints {
+		if endpoints == nil {
+			return copyEndpoints(defaults)
+		}
+
+		compiled := make(ApiEndpoints, len(endpoints))
+		for method, limits := range endpoints {
+			upper := strings.ToUpper(method)
+			if !knownMethods[upper] {
+				problems = append(problems, fmt.Sprintf("%s: unknown method %q", name, method))
+				continue
+			}
+			if compiled[upper] == nil {
+				compiled[upper] = make(map[*regexp.Regexp]*rate.Limiter, len(limits))
+			}
+
+			for template, limit := range limits {
+				endpoint := name + " " + upper + " " + template
+				matcher, err := templateRegexp(base, template)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s: %v", endpoint, err))
+					continue
+				}
+				if l := limiter(endpoint, limit); l != nil {
+					compiled[upper][matcher] = l
+				}
+			}
+		}
+
+		return &compiled
+	}
+
+	tables := &limiterTables{
+		dm: table("dataManagement", dataManagementUrl, c.DataManagement, &DefaultDataManagementLimits),
+		others: []*ApiEndpoints{
+			table("modelDerivative", modelDerivativeUrl, c.ModelDerivative, &DefaultModelDerivativeLimits),
+			table("reCap", reCapUrl, c.ReCap, &DefaultReCapLimits),
+			table("authentication", authenticationUrl, c.Authentication, &DefaultAuthenticationLimits),
+		},
+		oss:      copyLimiter(DefaultOSSLimiter.limiter),
+		fallback: copyLimiter(DefaultFallbackLimiter),
+	}
+	if c.OSS != nil {
+		tables.oss = limiter("oss", *c.OSS)
+	}
+	if c.Fallback != nil {
+		tables.fallback = limiter("fallback", *c.Fallback)
+	}
+
+	if len(problems) != 0 {
+		sort.Strings(problems)
+		return nil, &LimitsConfigError{Problems: problems}
+	}
+
+	return tables, nil
+}
+
+// copyEndpoints returns a table with the endpoints of defaults and new limiters, so that reloading the limits
+// never changes the default tables shared by the other RateLimiters
+func copyEndpoints(defaults *ApiEndpoints) *ApiEndpoints {
+	copied := make(ApiEndpoints, len(*defaults))
+	for method, limiters := range *defaults {
+		copied[method] = make(map[*regexp.Regexp]*rate.Limiter, len(limiters))
+		for matcher, limiter := range limiters {
+			copied[method][matcher] = copyLimiter(limiter)
+		}
+	}
+
+	return &copied
+}
+
+// copyLimiter returns a new limiter with the limit and burst of limiter
+func copyLimiter(limiter *rate.Limiter) *rate.Limiter {
+	return rate.NewLimiter(limiter.Limit(), limiter.Burst())
+}
+
+// variableName matches the variables of the path templates, such as {hub_id}
+var variableName = regexp.MustCompile(`^{[A-Za-z_][A-Za-z0-9_]*}$`)
+
+// templateRegexp returns the regexp matching the URLs of the path template, relative to base unless it is
+// an absolute URL. The variables match a single path segment, and the URLs may end with a query string.
+func templateRegexp(base, template string) (*regexp.Regexp, error) {
+	if len(strings.Trim(template, "/")) == 0 {
+		return nil, errors.New("empty pattern")
+	}
+	if strings.ContainsAny(template, " \t\r\n?#") {
+		return nil, errors.New("malformed pattern: whitespaces, query strings and fragments are not allowed")
+	}
+
+	var pattern strings.Builder
+	if strings.HasPrefix(template, "https://") || strings.HasPrefix(template, "http://") {
+		pattern.WriteString("^")
+	} else {
+		pattern.WriteString(base)
+		template = strings.TrimPrefix(template, "/")
+	}
+
+	literal := func(s string) error {
+		if strings.ContainsAny(s, "{}") {
+			return errors.New("malformed pattern: unbalanced braces")
+		}
+		pattern.WriteString(regexp.QuoteMeta(s))
+		return nil
+	}
+
+	last := 0
+	for _, location := range variableToRegexp.FindAllStringIndex(template, -1) {
+		if err := literal(template[last:location[0]]); err != nil {
+			return nil, err
+		}
+		if variable := template[location[0]:location[1]]; !variableName.MatchString(variable) {
+			return nil, fmt.Errorf("malformed pattern: invalid variable %s", variable)
+		}
+		pattern.WriteString("[^/]+")
+		last = location[1]
+	}
+	if err := literal(strings.TrimSuffix(template[last:], "/")); err != nil {
+		return nil, err
+	}
+	pattern.WriteString(`/?(\?.*)?$`)
+
+	return regexp.Compile(pattern.String())
+}
@@ -0,0 +1,316 @@
+package dm
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const limitsYAML = `
+dataManagement:
+  get:
+    hubs/{hub_id}/projects: {perMinute: 120, burst: 2}
+    projects/{project_id}/folders/{folder_id}: {perMinute: 600}
+modelDerivative:
+  POST:
+    job: {perMinute: 30}
+authentication:
+  GET:
+    https://developer.api.autodesk.com/userprofile/v1/users/@me: {perMinute: 60}
+fallback: {perMinute: 6}
+`
+
+const limitsJSON = `{
+	"dataManagement": {"GET": {"hubs/{hub_id}/projects": {"perMinute": 120, "burst": 2}}},
+	"oss": {"perMinute": 300}
+}`
+
+func TestNewRateLimiterFromConfig(t *testing.T) {
+
+	const host = "https://developer.api.autodesk.com"
+
+	config, err := ParseLimitsConfig([]byte(limitsYAML))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	limiter, err := NewRateLimiterFromConfig(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	tests := []struct {
+		method   string
+		url      string
+		endpoint string
+		limit    rate.Limit
+		burst    int
+	}{
+		{"GET", host + "/data/v1/hubs/h/projects?page[limit]=10", `hubs/[^/]+/projects/?(\?.*)?$`, 2, 2},
+		{"GET", host + "/data/v1/projects/p/folders/f/", `folders/[^/]+/?(\?.*)?$`, 10, 1},
+		{"POST", host + "/modelderivative/v2/regions/eu/designdata/job", `designdata/job/?(\?.*)?$`, 0.5, 1},
+		{"GET", host + "/userprofile/v1/users/@me", `users/@me/?(\?.*)?$`, 1, 1},
+		{"GET", host + "/data/v1/projects/p/folders/f/contents", "fallback", 0.1, 1},
+		{"GET", host + "/photo-to-3d/v1/photoscene/p/progress", `[^/]+/progress$`, 5, 1},
+		{"GET", host + "/oss/v2/buckets", "oss", DefaultOSSLimiter.limiter.Limit(), 1},
+	}
+
+	for _, test := range tests {
+		l, _, endpoint := limiter.endpoint(test.method, test.url)
+		if !strings.HasSuffix(endpoint, test.endpoint) {
+			t.Errorf("Expected %s %s to match %s, got %s", test.method, test.url, test.endpoint, endpoint)
+		}
+		if l.Limit() != test.limit || l.Burst() != test.burst {
+			t.Errorf("Expected %s %s to be limited to %v/s with a burst of %d, got %v/s and %d",
+				test.method, test.url, test.limit, test.burst, l.Limit(), l.Burst())
+		}
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		config, err := ParseLimitsConfig([]byte(limitsJSON))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		limiter, err := NewRateLimiterFromConfig(config)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if l := limiter.limiter("GET", host+"/data/v2/hubs/h/projects"); l.Limit() != 2 || l.Burst() != 2 {
+			t.Errorf("Expected the limit of the JSON file, got %v/s and %d", l.Limit(), l.Burst())
+		}
+		if l := limiter.limiter("GET", host+"/oss/v2/buckets"); l.Limit() != 5 {
+			t.Errorf("Expected the OSS limit of the JSON file, got %v/s", l.Limit())
+		}
+	})
+}
+
+func TestLimitsConfig_Validate(t *testing.T) {
+
+	config := &LimitsConfig{
+		DataManagement: EndpointsConfig{
+			"FETCH": {"hubs": {PerMinute: 50}},
+			"GET": {
+				"hubs/{hub_id":         {PerMinute: 50},
+				"hubs/{1hub}/projects": {PerMinute: 50},
+				"hubs?filter=x":        {PerMinute: 50},
+				"hubs/{hub_id}":        {PerMinute: 0},
+				"projects/{id}/items":  {PerMinute: 50, Burst: -1},
+			},
+		},
+		Fallback: &LimitConfig{PerMinute: -1},
+	}
+
+	err := config.Validate()
+	var configError *LimitsConfigError
+	if !errors.As(err, &configError) {
+		t.Fatalf("Expected a LimitsConfigError, got %v", err)
+	}
+
+	expected := []string{
+		`dataManagement: unknown method "FETCH"`,
+		"dataManagement GET hubs/{hub_id: malformed pattern: unbalanced braces",
+		"dataManagement GET hubs/{1hub}/projects: malformed pattern: invalid variable {1hub}",
+		"dataManagement GET hubs?filter=x: malformed pattern",
+		"dataManagement GET hubs/{hub_id}: perMinute must be positive",
+		"dataManagement GET projects/{id}/items: burst cannot be negative",
+		"fallback: perMinute must be positive",
+	}
+	if len(configError.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %q", len(expected), configError.Problems)
+	}
+	for _, problem := range expected {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the problem %q to be reported, got %s", problem, err.Error())
+		}
+	}
+
+	if _, err := ParseLimitsConfig([]byte("fallback: {perMinut: 50}")); err == nil {
+		t.Error("Expected the unknown fields to be rejected")
+	}
+}
+
+func TestRateLimiter_Reload(t *testing.T) {
+
+	const url = "https://developer.api.autodesk.com/data/v1/projects/p/folders/f"
+
+	config := &LimitsConfig{DataManagement: EndpointsConfig{"GET": {
+		"projects/{project_id}/folders/{folder_id}": {PerMinute: 600},
+	}}}
+	limiter, err := NewRateLimiterFromConfig(config)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	folder := limiter.limiter("GET", url)
+
+	// a request waits for the next token while the limits are reloaded
+	if err := limiter.Wait(context.Background(), "GET", url); err != nil {
+		t.Fatal(err.Error())
+	}
+	waited := make(chan error)
+	go func() {
+		waited <- limiter.Wait(context.Background(), "GET", url)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	observe(limiter, url, http.StatusTooManyRequests, map[string]string{"Retry-After": "0"})
+	config.DataManagement["GET"]["projects/{project_id}/folders/{folder_id}"] = LimitConfig{PerMinute: 1200, Burst: 3}
+	if err := limiter.Reload(config); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Errorf("Expected the waiting request to complete, got %s", err.Error())
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the waiting request to complete")
+	}
+
+	if l := limiter.limiter("GET", url); l != folder || l.Burst() != 3 {
+		t.Errorf("Expected the limiter of the endpoint to be updated in place")
+	}
+	if state := limiter.State()[0]; state.BaseLimit != 20 || state.Limit != 5 {
+		t.Errorf("Expected the throttled endpoint to keep its lower limit below the new one, got %+v", state)
+	}
+
+	t.Run("Invalid config", func(t *testing.T) {
+		invalid := &LimitsConfig{Fallback: &LimitConfig{}}
+		if err := limiter.Reload(invalid); err == nil {
+			t.Fatal("Expected the invalid limits to be rejected")
+		}
+		if l := limiter.limiter("GET", url); l != folder {
+			t.Errorf("Expected the limits to be unchanged")
+		}
+	})
+}
+
+func TestRateLimiter_WatchLimitsFile(t *testing.T) {
+
+	const url = "https://developer.api.autodesk.com/data/v1/hubs/h/projects"
+
+	dir, err := ioutil.TempDir("", "limits")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "limits.yaml")
+
+	// the file is replaced by renaming, as WatchLimitsFile requires, so that no check reads it partially written
+	write := func(t *testing.T, content string) {
+		t.Helper()
+		temporary := filepath.Join(dir, "limits.yaml.tmp")
+		if err := ioutil.WriteFile(temporary, []byte(content), 0644); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := os.Rename(temporary, path); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	write(t, limitsYAML)
+
+	limiter, err := NewRateLimiterFromConfig(&LimitsConfig{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	errs := make(chan error, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go limiter.WatchLimitsFile(ctx, path, 5*time.Millisecond, func(err error) { errs <- err })
+
+	eventually := func(condition func() bool, message string) {
+		t.Helper()
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if condition() {
+				return
+			}
+		}
+		t.Fatal(message)
+	}
+
+	eventually(func() bool { return limiter.limiter("GET", url).Limit() == 2 }, "Expected the file to be loaded")
+
+	write(t, strings.Replace(limitsYAML, "perMinute: 120", "perMinute: 240", 1))
+	eventually(func() bool { return limiter.limiter("GET", url).Limit() == 4 }, "Expected the changes to be reloaded")
+
+	rejected := func(t *testing.T, content, reason string) {
+		t.Helper()
+		write(t, content)
+		select {
+		case err := <-errs:
+			if !strings.Contains(err.Error(), reason) {
+				t.Errorf("Expected the file to be rejected with %q, got %s", reason, err.Error())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the file to be rejected")
+		}
+		if l := limiter.limiter("GET", url); l.Limit() != 4 {
+			t.Errorf("Expected the limits to be unchanged by the rejected file, got %v/s", l.Limit())
+		}
+	}
+
+	rejected(t, "dataManagement: {FETCH: {}}", "unknown method")
+
+	t.Run("Incomplete file", func(t *testing.T) {
+		rejected(t, "", "empty document")
+		rejected(t, " \n", "empty document")
+		truncated := limitsYAML[:strings.Index(limitsYAML, "perMinute: 120")+len("perMin")]
+		rejected(t, truncated, "could not parse")
+		if l := limiter.limiter("GET", "https://developer.api.autodesk.com/unknown"); l.Limit() != 0.1 {
+			t.Errorf("Expected the fallback limit to be unchanged, got %v/s", l.Limit())
+		}
+	})
+}
+
+func TestRateLimiter_ReloadKeepsDefaults(t *testing.T) {
+
+	const host = "https://developer.api.autodesk.com"
+	const folderUrl = host + "/data/v1/projects/p/folders/f"
+
+	fallback := DefaultFallbackLimiter.Limit()
+	oss := DefaultOSSLimiter.limiter.Limit()
+	folder := DefaultRateLimiter.limiter("GET", folderUrl).Limit()
+
+	fromConfig, err := NewRateLimiterFromConfig(&LimitsConfig{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sharingDefaults := NewRateLimiter(&DefaultDataManagementLimits, &DefaultOSSLimiter, DefaultFallbackLimiter)
+
+	config := &LimitsConfig{
+		Fallback: &LimitConfig{PerMinute: 6},
+		OSS:      &LimitConfig{PerMinute: 60},
+	}
+	for _, limiter := range []*RateLimiter{fromConfig, sharingDefaults} {
+		if err := limiter.Reload(config); err != nil {
+			t.Fatal(err.Error())
+		}
+		if l := limiter.limiter("GET", host+"/unknown"); l.Limit() != 0.1 {
+			t.Errorf("Expected the reloaded fallback limit, got %v/s", l.Limit())
+		}
+		if l := limiter.limiter("GET", host+"/oss/v2/buckets"); l.Limit() != 1 {
+			t.Errorf("Expected the reloaded OSS limit, got %v/s", l.Limit())
+		}
+	}
+
+	if l := DefaultFallbackLimiter.Limit(); l != fallback {
+		t.Errorf("Expected DefaultFallbackLimiter to be unchanged, got %v/s", l)
+	}
+	if l := DefaultOSSLimiter.limiter.Limit(); l != oss {
+		t.Errorf("Expected DefaultOSSLimiter to be unchanged, got %v/s", l)
+	}
+	if l := DefaultRateLimiter.limiter("GET", host+"/unknown"); l != DefaultFallbackLimiter || l.Limit() != fallback {
+		t.Errorf("Expected DefaultRateLimiter to keep its fallback limit, got %v/s", l.Limit())
+	}
+	if l := DefaultRateLimiter.limiter("GET", folderUrl); l.Limit() != folder {
+		t.Errorf("Expected DefaultRateLimiter to keep its endpoint limits, got %v/s", l.Limit())
+	}
+}
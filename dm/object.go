@@ -5,14 +5,13 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"strconv"
 	"sync"
 	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
 // ObjectDetails reflects the data presented when uploading an object to a bucket or requesting details on object.
@@ -22,10 +21,10 @@ type ObjectDetails struct {
 	ObjectKey   string            `json:"objectKey"`
 	SHA1        string            `json:"sha1"`
 	Size        uint64            `json:"size"`
-	ContentType string            `json:"contentType, omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
 	Location    string            `json:"location"`
-	BlockSizes  []int64           `json:"blockSizes, omitempty"`
-	Deltas      map[string]string `json:"deltas, omitempty"`
+	BlockSizes  []int64           `json:"blockSizes,omitempty"`
+	Deltas      map[string]string `json:"deltas,omitempty"`
 }
 
 // BucketContent reflects the response when query Data Management API for bucket content.
@@ -43,7 +42,7 @@ func (api BucketAPI) UploadObject(ctx context.Context, bucketKey string, objectN
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return uploadObject(ctx, api.RateLimiter, path, bucketKey, objectName, reader, bearer.AccessToken)
+	return uploadObject(ctx, api.Client, api.RateLimiter, path, bucketKey, objectName, reader, bearer.AccessToken)
 }
 
 // DownloadObject returns the reader stream of the response body
@@ -55,7 +54,7 @@ func (api BucketAPI) DownloadObject(ctx context.Context, bucketKey string, objec
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return downloadObject(ctx, api.RateLimiter, path, bucketKey, objectName, bearer.AccessToken)
+	return downloadObject(ctx, api.Client, api.RateLimiter, path, bucketKey, objectName, bearer.AccessToken)
 }
 
 // ListObjects returns the bucket contains along with details on each item.
@@ -66,17 +65,15 @@ func (api BucketAPI) ListObjects(ctx context.Context, bucketKey, limit, beginsWi
 	}
 	path := api.Host + api.BucketAPIPath
 
-	return listObjects(ctx, api.RateLimiter, path, bucketKey, limit, beginsWith, startAt, bearer.AccessToken)
+	return listObjects(ctx, api.Client, api.RateLimiter, path, bucketKey, limit, beginsWith, startAt, bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
 
-func listObjects(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, limit, beginsWith, startAt, token string) (result BucketContent, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func listObjects(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, limit, beginsWith, startAt, token string) (result BucketContent, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+bucketKey+"/objects",
 		nil,
 	)
@@ -99,15 +96,14 @@ func listObjects(ctx context.Context, limiter HttpRequestLimiter, path, bucketKe
 	req.URL.RawQuery = params.Encode()
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -119,7 +115,7 @@ func listObjects(ctx context.Context, limiter HttpRequestLimiter, path, bucketKe
 
 const maxUploadThreshold = 100000000
 
-func uploadObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, objectName string, dataContent io.Reader, token string) (result ObjectDetails, err error) {
+func uploadObject(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, objectName string, dataContent io.Reader, token string) (result ObjectDetails, err error) {
 	buf := &bytes.Buffer{}
 	nRead, err := io.Copy(buf, dataContent)
 	if err != nil {
@@ -127,20 +123,18 @@ func uploadObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketK
 	}
 
 	if nRead > maxUploadThreshold {
-		if _, err := putObjectChunked(ctx, limiter, path, bucketKey, objectName, buf, token); err != nil {
+		if _, err := putObjectChunked(ctx, client, limiter, path, bucketKey, objectName, buf, token); err != nil {
 			return ObjectDetails{}, err
 		}
 
-		return waitForObjectRecombination(ctx, limiter, path, bucketKey, objectName, token)
+		return waitForObjectRecombination(ctx, client, limiter, path, bucketKey, objectName, token)
 	}
 
-	return putObject(ctx, limiter, path, bucketKey, objectName, buf, token)
+	return putObject(ctx, client, limiter, path, bucketKey, objectName, buf, token)
 }
 
-func putObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, objectName string, dataContent io.Reader, token string) (result ObjectDetails, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "PUT",
+func putObject(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, objectName string, dataContent io.Reader, token string) (result ObjectDetails, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "PUT",
 		path+"/"+bucketKey+"/objects/"+objectName,
 		dataContent)
 
@@ -149,7 +143,7 @@ func putObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey,
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 
 	if err != nil {
 		return
@@ -157,8 +151,7 @@ func putObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey,
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -169,7 +162,7 @@ func putObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey,
 
 const chunkSize = 5000000
 
-func putObjectChunked(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, objectName string, data *bytes.Buffer, token string) (result ObjectDetails, err error) {
+func putObjectChunked(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, objectName string, data *bytes.Buffer, token string) (result ObjectDetails, err error) {
 	total := int64(data.Len())
 	sessionId := fmt.Sprintf("%x-%d", md5.Sum([]byte(objectName)), time.Now().Unix())
 
@@ -196,8 +189,7 @@ func putObjectChunked(ctx context.Context, limiter HttpRequestLimiter, path, buc
 			go func(remaining, size int64, chunk *bytes.Buffer) {
 				defer wg.Done()
 
-				task := http.Client{}
-				req, err := limiter.HttpRequest(ctx, "PUT",
+				req, err := forge.NewRequest(ctx, limiter, "PUT",
 					path+"/"+bucketKey+"/objects/"+objectName+"/resumable",
 					chunk,
 				)
@@ -213,9 +205,10 @@ func putObjectChunked(ctx context.Context, limiter HttpRequestLimiter, path, buc
 				req.Header.Set("Content-Type", "application/stream")
 				req.Header.Set("Content-Length", fmt.Sprintf("%d", size))
 
-				response, err := task.Do(req)
+				response, err := forge.Do(client, limiter, req)
 				if err != nil {
 					errChan <- fmt.Errorf("failed to execute request: %w", err)
+					return
 				}
 				defer response.Body.Close()
 
@@ -234,8 +227,7 @@ func putObjectChunked(ctx context.Context, limiter HttpRequestLimiter, path, buc
 					resultChan <- output
 
 				default:
-					content, _ := ioutil.ReadAll(response.Body)
-					errChan <- errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+					errChan <- forge.NewAPIError(response)
 				}
 			}(remaining, size, chunk)
 
@@ -256,10 +248,8 @@ func putObjectChunked(ctx context.Context, limiter HttpRequestLimiter, path, buc
 // The Forge API doesn't give us many clues out when a chunked upload is recombined.
 // The only way to be sure is to poll the object details API until the SHA1 hash
 // is populated.
-func waitForObjectRecombination(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, objectName, token string) (result ObjectDetails, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func waitForObjectRecombination(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, objectName, token string) (result ObjectDetails, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+bucketKey+"/objects/"+objectName+"/details",
 		nil,
 	)
@@ -277,7 +267,7 @@ func waitForObjectRecombination(ctx context.Context, limiter HttpRequestLimiter,
 	for {
 		select {
 		case <-ticker.C:
-			response, err := task.Do(req)
+			response, err := forge.Do(client, limiter, req)
 			if err != nil {
 				return ObjectDetails{}, fmt.Errorf("failed to execute request: %w", err)
 			}
@@ -295,8 +285,7 @@ func waitForObjectRecombination(ctx context.Context, limiter HttpRequestLimiter,
 				}
 
 			default:
-				content, _ := ioutil.ReadAll(response.Body)
-				return ObjectDetails{}, errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+				return ObjectDetails{}, forge.NewAPIError(response)
 			}
 
 		case <-timeout:
@@ -305,10 +294,8 @@ func waitForObjectRecombination(ctx context.Context, limiter HttpRequestLimiter,
 	}
 }
 
-func downloadObject(ctx context.Context, limiter HttpRequestLimiter, path, bucketKey, objectName string, token string) (result io.ReadCloser, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func downloadObject(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, bucketKey, objectName string, token string) (result io.ReadCloser, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+bucketKey+"/objects/"+objectName,
 		nil)
 
@@ -317,14 +304,15 @@ func downloadObject(ctx context.Context, limiter HttpRequestLimiter, path, bucke
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 
 	if err != nil {
 		return
 	}
 
 	if response.StatusCode != http.StatusOK {
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] ")
+		err = forge.NewAPIError(response)
+		response.Body.Close()
 		return
 	}
 	return response.Body, nil
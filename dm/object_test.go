@@ -3,9 +3,14 @@ package dm
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
+	"net/http"
 	"os"
+	"strings"
 	"testing"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
 func TestBucketAPI_ListObjects(t *testing.T) {
@@ -224,3 +229,17 @@ func deleteTempBucket(bucketAPI BucketAPI, bucketKey string, t *testing.T) {
 		t.Error("Could not delete temp bucket, got: ", err.Error())
 	}
 }
+
+func TestPutObjectChunked_RequestError(t *testing.T) {
+	client := forge.NewClient(forge.WithMiddleware(forge.Retry(forge.NoRetry), func(next http.RoundTripper) http.RoundTripper {
+		return forge.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection reset")
+		})
+	}))
+
+	data := bytes.NewBufferString("chunk")
+	_, err := putObjectChunked(context.Background(), client, DefaultRateLimiter, "https://developer.api.autodesk.com/oss/v2/buckets", "bucket", "object", data, "token")
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("Expected the error of the chunk request, got %v", err)
+	}
+}
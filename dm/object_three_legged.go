@@ -13,7 +13,7 @@ func (api BucketAPI3L) UploadObject3L(ctx context.Context, bucketKey string, obj
 	}
 
 	path := api.Auth.Host + api.BucketsAPIPath
-	return uploadObject(ctx, api.RateLimiter, path, bucketKey, objectName, reader, api.Token.Bearer().AccessToken)
+	return uploadObject(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, objectName, reader, api.Token.Bearer().AccessToken)
 }
 
 // DownloadObject returns the reader stream of the response body
@@ -24,7 +24,7 @@ func (api BucketAPI3L) DownloadObject3L(ctx context.Context, bucketKey string, o
 	}
 
 	path := api.Auth.Host + api.BucketsAPIPath
-	return downloadObject(ctx, api.RateLimiter, path, bucketKey, objectName, api.Token.Bearer().AccessToken)
+	return downloadObject(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, objectName, api.Token.Bearer().AccessToken)
 }
 
 // ListObjects returns the bucket contains along with details on each item.
@@ -34,5 +34,5 @@ func (api BucketAPI3L) ListObjects3L(ctx context.Context, bucketKey, limit, begi
 	}
 
 	path := api.Auth.Host + api.BucketsAPIPath
-	return listObjects(ctx, api.RateLimiter, path, bucketKey, limit, beginsWith, startAt, api.Token.Bearer().AccessToken)
+	return listObjects(ctx, api.Auth.Client, api.RateLimiter, path, bucketKey, limit, beginsWith, startAt, api.Token.Bearer().AccessToken)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
 // ListBuckets returns a list of all buckets created or associated with Forge secrets used for token creation
@@ -18,7 +20,7 @@ func (api HubAPI) ListProjects(ctx context.Context, hubKey string) (result Forge
 
 	path := api.Host + api.HubAPIPath
 
-	return listProjects(ctx, api.RateLimiter, path, hubKey, "", "", "", "", bearer.AccessToken)
+	return listProjects(ctx, api.Client, api.RateLimiter, path, hubKey, "", "", "", "", bearer.AccessToken)
 }
 
 func (api HubAPI) GetProjectDetails(ctx context.Context, hubKey, projectKey string) (result ForgeResponseObject, err error) {
@@ -28,7 +30,7 @@ func (api HubAPI) GetProjectDetails(ctx context.Context, hubKey, projectKey stri
 	}
 	path := api.Host + api.HubAPIPath
 
-	return getProjectDetails(ctx, api.RateLimiter, path, hubKey, projectKey, bearer.AccessToken)
+	return getProjectDetails(ctx, api.Client, api.RateLimiter, path, hubKey, projectKey, bearer.AccessToken)
 }
 
 func (api HubAPI) GetTopFolders(ctx context.Context, hubKey, projectKey string) (result ForgeResponseArray, err error) {
@@ -38,16 +40,14 @@ func (api HubAPI) GetTopFolders(ctx context.Context, hubKey, projectKey string)
 	}
 	path := api.Host + api.HubAPIPath
 
-	return getTopFolders(ctx, api.RateLimiter, path, hubKey, projectKey, bearer.AccessToken)
+	return getTopFolders(ctx, api.Client, api.RateLimiter, path, hubKey, projectKey, bearer.AccessToken)
 }
 
 /*
  *	SUPPORT FUNCTIONS
  */
-func listProjects(ctx context.Context, limiter HttpRequestLimiter, path, hubKey, id, extension, page, limit string, token string) (result ForgeResponseArray, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func listProjects(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, hubKey, id, extension, page, limit string, token string) (result ForgeResponseArray, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+hubKey+"/projects",
 		nil,
 	)
@@ -73,7 +73,7 @@ func listProjects(ctx context.Context, limiter HttpRequestLimiter, path, hubKey,
 	req.URL.RawQuery = params.Encode()
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -81,8 +81,7 @@ func listProjects(ctx context.Context, limiter HttpRequestLimiter, path, hubKey,
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -91,10 +90,8 @@ func listProjects(ctx context.Context, limiter HttpRequestLimiter, path, hubKey,
 	return
 }
 
-func getProjectDetails(ctx context.Context, limiter HttpRequestLimiter, path, hubKey, projectKey, token string) (result ForgeResponseObject, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func getProjectDetails(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, hubKey, projectKey, token string) (result ForgeResponseObject, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+hubKey+"/projects/"+projectKey,
 		nil,
 	)
@@ -104,7 +101,7 @@ func getProjectDetails(ctx context.Context, limiter HttpRequestLimiter, path, hu
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -112,8 +109,7 @@ func getProjectDetails(ctx context.Context, limiter HttpRequestLimiter, path, hu
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -122,10 +118,8 @@ func getProjectDetails(ctx context.Context, limiter HttpRequestLimiter, path, hu
 	return
 }
 
-func getTopFolders(ctx context.Context, limiter HttpRequestLimiter, path, hubKey, projectKey, token string) (result ForgeResponseArray, err error) {
-	task := http.Client{}
-
-	req, err := limiter.HttpRequest(ctx, "GET",
+func getTopFolders(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path, hubKey, projectKey, token string) (result ForgeResponseArray, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+hubKey+"/projects/"+projectKey+"/topFolders",
 		nil,
 	)
@@ -135,7 +129,7 @@ func getTopFolders(ctx context.Context, limiter HttpRequestLimiter, path, hubKey
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -143,8 +137,7 @@ func getTopFolders(ctx context.Context, limiter HttpRequestLimiter, path, hubKey
 
 	decoder := json.NewDecoder(response.Body)
 	if response.StatusCode != http.StatusOK {
-		err = &ErrorResult{StatusCode: response.StatusCode}
-		decoder.Decode(err)
+		err = forge.NewAPIError(response)
 		return
 	}
 
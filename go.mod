@@ -4,8 +4,9 @@ go 1.15
 
 require (
 	cloud.google.com/go/storage v1.12.0 // indirect
-	github.com/apprentice3d/forge-api-go-client v0.2.5
 	github.com/gorilla/mux v1.8.0 // indirect
 	github.com/gorilla/sessions v1.2.1 // indirect
+	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
 	golang.org/x/time v0.0.0-20200630173020-3af7569d3a1e
+	gopkg.in/yaml.v2 v2.4.0
 )
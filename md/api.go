@@ -2,15 +2,14 @@ package md
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"io"
-	"io/ioutil"
 	"log"
 	"net/http"
-	"strconv"
 
+	forge "github.com/outer-labs/forge-api-go-client"
 	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
@@ -34,13 +33,16 @@ var (
 type ModelDerivativeAPI struct {
 	oauth.TwoLeggedAuth
 	ModelDerivativePath string
+	RateLimiter         HttpRequestLimiter
 }
 
-// NewAPIWithCredentials returns a Model Derivative API client with default configurations
-func NewAPIWithCredentials(ClientID string, ClientSecret string) ModelDerivativeAPI {
+// NewAPIWithCredentials returns a Model Derivative API client with default configurations.
+// The requests are not rate limited when limiter is nil.
+func NewAPIWithCredentials(ClientID string, ClientSecret string, limiter HttpRequestLimiter) ModelDerivativeAPI {
 	return ModelDerivativeAPI{
 		oauth.NewTwoLeggedClient(ClientID, ClientSecret),
 		"/modelderivative/v2/designdata",
+		limiter,
 	}
 }
 
@@ -49,14 +51,17 @@ type ModelDerivativeAPI3L struct {
 	Auth                oauth.ThreeLeggedAuth
 	Token               TokenRefresher
 	ModelDerivativePath string
+	RateLimiter         HttpRequestLimiter
 }
 
-// NewAPIWithCredentials returns a Model Derivative API client with default configurations
-func NewAPI3LWithCredentials(auth oauth.ThreeLeggedAuth, token *oauth.RefreshableToken) *ModelDerivativeAPI3L {
+// NewAPI3LWithCredentials returns a Model Derivative API client acting on behalf of the user who granted the token.
+// The requests are not rate limited when limiter is nil.
+func NewAPI3LWithCredentials(auth oauth.ThreeLeggedAuth, token *oauth.RefreshableToken, limiter HttpRequestLimiter) *ModelDerivativeAPI3L {
 	return &ModelDerivativeAPI3L{
 		Auth:                auth,
 		Token:               token,
 		ModelDerivativePath: "/modelderivative/v2/designdata",
+		RateLimiter:         limiter,
 	}
 }
 
@@ -126,23 +131,23 @@ type FormatSpec struct {
 }
 
 type MetadataResult struct {
-	Data MetadataSpec `json:"data",omitempty`
+	Data MetadataSpec `json:"data,omitempty"`
 }
 
 type MetadataSpec struct {
-	Type     string     `json:"type",omitempty`
-	Metadata []ViewSpec `json:"metadata",omitempty`
+	Type     string     `json:"type,omitempty"`
+	Metadata []ViewSpec `json:"metadata,omitempty"`
 }
 
 type ViewSpec struct {
-	Name string `json:"name",omitempty`
-	Role string `json:"role",omitempty`
-	Guid string `json:"guid",omitempty`
+	Name string `json:"name,omitempty"`
+	Role string `json:"role,omitempty"`
+	Guid string `json:"guid,omitempty"`
 }
 
 type PropertiesResult struct {
-	Data   PropertiesSpec `json:"data",omitempty`
-	Result string         `json:"result",omitempty`
+	Data   PropertiesSpec `json:"data,omitempty"`
+	Result string         `json:"result,omitempty"`
 }
 
 type PropertiesSpec struct {
@@ -158,28 +163,33 @@ type ObjectSpec struct {
 }
 
 type TreeResult struct {
-	Data TreeSpec `json:"data",omitempty`
+	Data TreeSpec `json:"data,omitempty"`
 }
 
 type TreeSpec struct {
-	Type    string         `json:"type",omitempty`
-	Objects []TreeNodeSpec `json:"objects",omitempty`
+	Type    string         `json:"type,omitempty"`
+	Objects []TreeNodeSpec `json:"objects,omitempty"`
 }
 
 type TreeNodeSpec struct {
-	ObjectID int64          `json:"objectid",omitempty`
-	Name     string         `json:"name",omitempty`
-	Objects  []TreeNodeSpec `json:"objects",omitempty`
+	ObjectID int64          `json:"objectid,omitempty"`
+	Name     string         `json:"name,omitempty"`
+	Objects  []TreeNodeSpec `json:"objects,omitempty"`
 }
 
 // TranslateWithParams triggers translation job with settings specified in given TranslationParams
 func (a ModelDerivativeAPI) TranslateWithParams(params TranslationParams) (result TranslationResult, err error) {
+	return a.TranslateWithParamsContext(context.Background(), params)
+}
+
+// TranslateWithParamsContext is TranslateWithParams with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) TranslateWithParamsContext(ctx context.Context, params TranslationParams) (result TranslationResult, err error) {
 	bearer, err := a.Authenticate("data:write data:read")
 	if err != nil {
 		return
 	}
 	path := a.Host + a.ModelDerivativePath
-	result, err = translate(path, params, bearer.AccessToken)
+	result, err = translate(ctx, a.Client, a.RateLimiter, path, params, bearer.AccessToken)
 
 	return
 }
@@ -187,6 +197,11 @@ func (a ModelDerivativeAPI) TranslateWithParams(params TranslationParams) (resul
 // TranslateToSVF is a helper function that will use the TranslationSVFPreset for translating into svf a given ObjectID.
 // It will also take care of converting objectID into Base64 (URL Safe) encoded URN.
 func (a ModelDerivativeAPI) TranslateToSVF(objectID string) (result TranslationResult, err error) {
+	return a.TranslateToSVFContext(context.Background(), objectID)
+}
+
+// TranslateToSVFContext is TranslateToSVF with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) TranslateToSVFContext(ctx context.Context, objectID string) (result TranslationResult, err error) {
 	bearer, err := a.Authenticate("data:write data:read")
 	if err != nil {
 		return
@@ -195,81 +210,118 @@ func (a ModelDerivativeAPI) TranslateToSVF(objectID string) (result TranslationR
 	params := TranslationSVFPreset
 	params.Input.URN = base64.RawURLEncoding.EncodeToString([]byte(objectID))
 
-	result, err = translate(path, params, bearer.AccessToken)
+	result, err = translate(ctx, a.Client, a.RateLimiter, path, params, bearer.AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI) GetManifest(urn string) (result ManifestResult, err error) {
+	return a.GetManifestContext(context.Background(), urn)
+}
+
+// GetManifestContext is GetManifest with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) GetManifestContext(ctx context.Context, urn string) (result ManifestResult, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
 		return
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	result, err = getManifest(path, urn, bearer.AccessToken)
+	result, err = getManifest(ctx, a.Client, a.RateLimiter, path, urn, bearer.AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI3L) GetManifest3L(urn string) (result ManifestResult, err error) {
+	return a.GetManifest3LContext(context.Background(), urn)
+}
+
+// GetManifest3LContext is GetManifest3L with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI3L) GetManifest3LContext(ctx context.Context, urn string) (result ManifestResult, err error) {
 	if err = a.Token.RefreshTokenIfRequired(a.Auth); err != nil {
 		return
 	}
 
 	path := a.Auth.Host + a.ModelDerivativePath
-	result, err = getManifest(path, urn, a.Token.Bearer().AccessToken)
+	result, err = getManifest(ctx, a.Auth.Client, a.RateLimiter, path, urn, a.Token.Bearer().AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI) GetMetadata(urn string) (result MetadataResult, err error) {
+	return a.GetMetadataContext(context.Background(), urn)
+}
+
+// GetMetadataContext is GetMetadata with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) GetMetadataContext(ctx context.Context, urn string) (result MetadataResult, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
 		return
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	result, err = getMetadata(path, urn, bearer.AccessToken)
+	result, err = getMetadata(ctx, a.Client, a.RateLimiter, path, urn, bearer.AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI3L) GetMetadata3L(urn string) (result MetadataResult, err error) {
+	return a.GetMetadata3LContext(context.Background(), urn)
+}
+
+// GetMetadata3LContext is GetMetadata3L with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI3L) GetMetadata3LContext(ctx context.Context, urn string) (result MetadataResult, err error) {
 	if err = a.Token.RefreshTokenIfRequired(a.Auth); err != nil {
 		return
 	}
 
 	path := a.Auth.Host + a.ModelDerivativePath
-	result, err = getMetadata(path, urn, a.Token.Bearer().AccessToken)
+	result, err = getMetadata(ctx, a.Auth.Client, a.RateLimiter, path, urn, a.Token.Bearer().AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI) GetObjectTree(urn string, viewId string) (status int, result TreeResult, err error) {
+	return a.GetObjectTreeContext(context.Background(), urn, viewId)
+}
+
+// GetObjectTreeContext is GetObjectTree with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) GetObjectTreeContext(ctx context.Context, urn string, viewId string) (status int, result TreeResult, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
 		return
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	status, result, err = getObjectTree(path, urn, viewId, bearer.AccessToken)
+	status, result, err = getObjectTree(ctx, a.Client, a.RateLimiter, path, urn, viewId, bearer.AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI3L) GetObjectTree3L(urn string, viewId string) (status int, result TreeResult, err error) {
+	return a.GetObjectTree3LContext(context.Background(), urn, viewId)
+}
+
+// GetObjectTree3LContext is GetObjectTree3L with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI3L) GetObjectTree3LContext(ctx context.Context, urn string, viewId string) (status int, result TreeResult, err error) {
 	if err = a.Token.RefreshTokenIfRequired(a.Auth); err != nil {
 		return
 	}
 
 	path := a.Auth.Host + a.ModelDerivativePath
-	status, result, err = getObjectTree(path, urn, viewId, a.Token.Bearer().AccessToken)
+	status, result, err = getObjectTree(ctx, a.Auth.Client, a.RateLimiter, path, urn, viewId, a.Token.Bearer().AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI) GetPropertiesStream(urn string, viewId string) (status int,
+	result io.ReadCloser, err error) {
+	return a.GetPropertiesStreamContext(context.Background(), urn, viewId)
+}
+
+// GetPropertiesStreamContext is GetPropertiesStream with a context controlling the cancellation and the deadline of the request,
+// including the reading of the returned stream
+func (a ModelDerivativeAPI) GetPropertiesStreamContext(ctx context.Context, urn string, viewId string) (status int,
 	result io.ReadCloser, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
@@ -277,51 +329,75 @@ func (a ModelDerivativeAPI) GetPropertiesStream(urn string, viewId string) (stat
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	status, result, err = getPropertiesStream(path, urn, viewId, bearer.AccessToken)
+	status, result, err = getPropertiesStream(ctx, a.Client, a.RateLimiter, path, urn, viewId, bearer.AccessToken)
 	return
 }
 
 func (a ModelDerivativeAPI3L) GetPropertiesStream3L(urn string, viewId string) (status int,
+	result io.ReadCloser, err error) {
+	return a.GetPropertiesStream3LContext(context.Background(), urn, viewId)
+}
+
+// GetPropertiesStream3LContext is GetPropertiesStream3L with a context controlling the cancellation and the deadline of the request,
+// including the reading of the returned stream
+func (a ModelDerivativeAPI3L) GetPropertiesStream3LContext(ctx context.Context, urn string, viewId string) (status int,
 	result io.ReadCloser, err error) {
 	if err = a.Token.RefreshTokenIfRequired(a.Auth); err != nil {
 		return
 	}
 
 	path := a.Auth.Host + a.ModelDerivativePath
-	status, result, err = getPropertiesStream(path, urn, viewId, a.Token.Bearer().AccessToken)
+	status, result, err = getPropertiesStream(ctx, a.Auth.Client, a.RateLimiter, path, urn, viewId, a.Token.Bearer().AccessToken)
 	return
 }
 
 func (a ModelDerivativeAPI) GetPropertiesObject(urn string, viewId string) (result PropertiesResult, err error) {
+	return a.GetPropertiesObjectContext(context.Background(), urn, viewId)
+}
+
+// GetPropertiesObjectContext is GetPropertiesObject with a context controlling the cancellation and the deadline of the request
+func (a ModelDerivativeAPI) GetPropertiesObjectContext(ctx context.Context, urn string, viewId string) (result PropertiesResult, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
 		return
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	result, err = getPropertiesObject(path, urn, viewId, bearer.AccessToken)
+	result, err = getPropertiesObject(ctx, a.Client, a.RateLimiter, path, urn, viewId, bearer.AccessToken)
 	return
 }
 
 func (a ModelDerivativeAPI) GetThumbnail(urn string) (reader io.ReadCloser, err error) {
+	return a.GetThumbnailContext(context.Background(), urn)
+}
+
+// GetThumbnailContext is GetThumbnail with a context controlling the cancellation and the deadline of the request,
+// including the reading of the returned stream
+func (a ModelDerivativeAPI) GetThumbnailContext(ctx context.Context, urn string) (reader io.ReadCloser, err error) {
 	bearer, err := a.Authenticate("data:read")
 	if err != nil {
 		return
 	}
 
 	path := a.Host + a.ModelDerivativePath
-	reader, err = getThumbnail(path, urn, bearer.AccessToken)
+	reader, err = getThumbnail(ctx, a.Client, a.RateLimiter, path, urn, bearer.AccessToken)
 
 	return
 }
 
 func (a ModelDerivativeAPI3L) GetThumbnail3L(urn string) (reader io.ReadCloser, err error) {
+	return a.GetThumbnail3LContext(context.Background(), urn)
+}
+
+// GetThumbnail3LContext is GetThumbnail3L with a context controlling the cancellation and the deadline of the request,
+// including the reading of the returned stream
+func (a ModelDerivativeAPI3L) GetThumbnail3LContext(ctx context.Context, urn string) (reader io.ReadCloser, err error) {
 	if err = a.Token.RefreshTokenIfRequired(a.Auth); err != nil {
 		return
 	}
 
 	path := a.Auth.Host + a.ModelDerivativePath
-	reader, err = getThumbnail(path, urn, a.Token.Bearer().AccessToken)
+	reader, err = getThumbnail(ctx, a.Auth.Client, a.RateLimiter, path, urn, a.Token.Bearer().AccessToken)
 
 	return
 }
@@ -329,15 +405,14 @@ func (a ModelDerivativeAPI3L) GetThumbnail3L(urn string) (reader io.ReadCloser,
 /*
  *	SUPPORT FUNCTIONS
  */
-func translate(path string, params TranslationParams, token string) (result TranslationResult, err error) {
-	client := http.Client{}
+func translate(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, params TranslationParams, token string) (result TranslationResult, err error) {
 	byteParams, err := json.Marshal(params)
 	if err != nil {
 		log.Println("Could not marshal the translation parameters")
 		return
 	}
 
-	req, err := http.NewRequest("POST",
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/job",
 		bytes.NewBuffer(byteParams))
 
@@ -348,15 +423,14 @@ func translate(path string, params TranslationParams, token string) (result Tran
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	response, err := client.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusCreated && response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -367,10 +441,8 @@ func translate(path string, params TranslationParams, token string) (result Tran
 	return
 }
 
-func getManifest(path string, urn string, token string) (result ManifestResult, err error) {
-	client := http.Client{}
-
-	req, err := http.NewRequest("GET",
+func getManifest(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, token string) (result ManifestResult, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+urn+"/manifest",
 		nil)
 
@@ -381,15 +453,14 @@ func getManifest(path string, urn string, token string) (result ManifestResult,
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	response, err := client.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -400,10 +471,8 @@ func getManifest(path string, urn string, token string) (result ManifestResult,
 	return
 }
 
-func getThumbnail(path string, urn string, token string) (reader io.ReadCloser, err error) {
-	client := http.Client{}
-
-	req, err := http.NewRequest("GET",
+func getThumbnail(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, token string) (reader io.ReadCloser, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+urn+"/thumbnail",
 		nil)
 
@@ -414,14 +483,13 @@ func getThumbnail(path string, urn string, token string) (reader io.ReadCloser,
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	response, err := client.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -429,22 +497,9 @@ func getThumbnail(path string, urn string, token string) (reader io.ReadCloser,
 	return
 }
 
-func getPropertiesStream(path string, urn string, viewId string, token string) (
+func getPropertiesStream(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, viewId string, token string) (
 	statusCode int, result io.ReadCloser, err error) {
-	client := http.Client{}
-
-	req, err := http.NewRequest("GET",
-		path+"/"+urn+"/metadata/"+viewId+"/properties?forceget=true",
-		nil)
-
-	if err != nil {
-		return
-	}
-
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	response, err := client.Do(req)
+	response, err := getProperties(ctx, client, limiter, path, urn, viewId, token)
 	if err != nil {
 		return
 	}
@@ -454,31 +509,44 @@ func getPropertiesStream(path string, urn string, viewId string, token string) (
 	return
 }
 
-func getPropertiesObject(path string, urn string, viewId string, token string) (
+func getPropertiesObject(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, viewId string, token string) (
 	result PropertiesResult, err error) {
-	status, stream, err := getPropertiesStream(path, urn, viewId, token)
+	response, err := getProperties(ctx, client, limiter, path, urn, viewId, token)
 	if err != nil {
 		return
 	}
-	defer stream.Close()
+	defer response.Body.Close()
 
 	//using 200 as an error mask since it can be 2xx depending on state
-	if (status & http.StatusOK) == 0 {
-		content, _ := ioutil.ReadAll(stream)
-		err = errors.New("[" + strconv.Itoa(status) + "] " + string(content))
+	if (response.StatusCode & http.StatusOK) == 0 {
+		err = forge.NewAPIError(response)
 		return
 	}
 
-	decoder := json.NewDecoder(stream)
+	decoder := json.NewDecoder(response.Body)
 	err = decoder.Decode(&result)
 	return
 }
 
-func getMetadata(path string, urn string, token string) (
-	result MetadataResult, err error) {
-	client := http.Client{}
+func getProperties(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, viewId string, token string) (
+	response *http.Response, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
+		path+"/"+urn+"/metadata/"+viewId+"/properties?forceget=true",
+		nil)
+
+	if err != nil {
+		return
+	}
 
-	req, err := http.NewRequest("GET",
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	return forge.Do(client, limiter, req)
+}
+
+func getMetadata(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, token string) (
+	result MetadataResult, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+urn+"/metadata",
 		nil)
 
@@ -489,15 +557,14 @@ func getMetadata(path string, urn string, token string) (
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	response, err := client.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -508,11 +575,9 @@ func getMetadata(path string, urn string, token string) (
 	return
 }
 
-func getObjectTree(path string, urn string, viewId string, token string) (
+func getObjectTree(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, urn string, viewId string, token string) (
 	statusCode int, result TreeResult, err error) {
-	client := http.Client{}
-
-	req, err := http.NewRequest("GET",
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/"+urn+"/metadata/"+viewId+"?forceget=true",
 		nil)
 
@@ -523,7 +588,7 @@ func getObjectTree(path string, urn string, viewId string, token string) (
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Authorization", "Bearer "+token)
 
-	response, err := client.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
@@ -531,8 +596,7 @@ func getObjectTree(path string, urn string, viewId string, token string) (
 
 	statusCode = response.StatusCode
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
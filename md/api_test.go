@@ -0,0 +1,76 @@
+package md_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"github.com/outer-labs/forge-api-go-client/dm"
+	"github.com/outer-labs/forge-api-go-client/md"
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestAPI3L_Context(t *testing.T) {
+
+	hung := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/modelderivative/v2/designdata/urn/manifest":
+			json.NewEncoder(w).Encode(md.ManifestResult{URN: "urn", Status: "success"})
+		case "/modelderivative/v2/designdata/urn/metadata/view/properties":
+			select {
+			case <-hung:
+			case <-r.Context().Done():
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer close(hung)
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost/callback")
+	auth.Client = forge.NewClient(forge.WithHost(server.URL))
+	token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "access"}, time.Now().Add(time.Hour))
+
+	api := md.NewAPI3LWithCredentials(auth, token, dm.DefaultRateLimiter)
+
+	manifest, err := api.GetManifest3LContext(context.Background(), "urn")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if manifest.Status != "success" {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+
+	t.Run("Without limiter", func(t *testing.T) {
+		api := md.NewAPI3LWithCredentials(auth, token, nil)
+		if _, err := api.GetManifest3LContext(context.Background(), "urn"); err != nil {
+			t.Errorf("Expected the request to be sent without rate limiting, got %v", err)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, _, err := api.GetPropertiesStream3LContext(ctx, "urn", "view")
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the deadline to interrupt the request, got %v", err)
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if _, err := api.GetMetadata3LContext(ctx, "urn"); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the cancellation to be reported, got %v", err)
+		}
+	})
+}
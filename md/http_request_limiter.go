@@ -0,0 +1,11 @@
+package md
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type HttpRequestLimiter interface {
+	HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error)
+}
@@ -2,14 +2,15 @@ package md_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
 	"os"
 	"testing"
 
-	"github.com/apprentice3d/forge-api-go-client/dm"
-	"github.com/apprentice3d/forge-api-go-client/md"
+	"github.com/outer-labs/forge-api-go-client/dm"
+	"github.com/outer-labs/forge-api-go-client/md"
 )
 
 func TestAPI_TranslateToSVF(t *testing.T) {
@@ -21,8 +22,9 @@ func TestAPI_TranslateToSVF(t *testing.T) {
 		t.Skipf("No Forge credentials present; skipping test")
 	}
 
-	bucketAPI := dm.NewBucketAPIWithCredentials(clientID, clientSecret)
-	mdAPI := md.NewAPIWithCredentials(clientID, clientSecret)
+	ctx := context.Background()
+	bucketAPI := dm.NewBucketAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
+	mdAPI := md.NewAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
 
 	tempBucketName := "go_testing_md_bucket"
 	testFilePath := "../assets/HelloWorld.rvt"
@@ -30,7 +32,7 @@ func TestAPI_TranslateToSVF(t *testing.T) {
 	var testObject dm.ObjectDetails
 
 	t.Run("Create a temporary bucket", func(t *testing.T) {
-		_, err := bucketAPI.CreateBucket(tempBucketName, "transient")
+		_, err := bucketAPI.CreateBucket(ctx, tempBucketName, "transient")
 
 		if err != nil {
 			t.Errorf("Failed to create a bucket: %s\n", err.Error())
@@ -38,7 +40,7 @@ func TestAPI_TranslateToSVF(t *testing.T) {
 	})
 
 	t.Run("Get bucket details", func(t *testing.T) {
-		_, err := bucketAPI.GetBucketDetails(tempBucketName)
+		_, err := bucketAPI.GetBucketDetails(ctx, tempBucketName)
 
 		if err != nil {
 			t.Fatalf("Failed to get bucket details: %s\n", err.Error())
@@ -56,7 +58,7 @@ func TestAPI_TranslateToSVF(t *testing.T) {
 			t.Fatal("Cannot read the testfile")
 		}
 
-		testObject, err = bucketAPI.UploadObject(tempBucketName, "temp_file.rvt", data)
+		testObject, err = bucketAPI.UploadObject(ctx, tempBucketName, "temp_file.rvt", bytes.NewReader(data))
 
 		if err != nil {
 			t.Fatal("Could not upload the test object, got: ", err.Error())
@@ -81,7 +83,7 @@ func TestAPI_TranslateToSVF(t *testing.T) {
 	})
 
 	t.Run("Delete the temporary bucket", func(t *testing.T) {
-		err := bucketAPI.DeleteBucket(tempBucketName)
+		err := bucketAPI.DeleteBucket(ctx, tempBucketName)
 
 		if err != nil {
 			t.Fatalf("Failed to delete bucket: %s\n", err.Error())
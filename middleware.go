@@ -0,0 +1,132 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// TokenSource provides the access token the Bearer middleware sends with the requests
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenSourceFunc is a function acting as a TokenSource
+type TokenSourceFunc func(ctx context.Context) (string, error)
+
+// Token calls f(ctx)
+func (f TokenSourceFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// Bearer returns a middleware authorizing the requests that have no Authorization header
+// with a token from source
+func Bearer(source TokenSource) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if len(req.Header.Get("Authorization")) != 0 {
+				return next.RoundTrip(req)
+			}
+
+			token, err := source.Token(req.Context())
+			if err != nil {
+				return nil, fmt.Errorf("could not get a token: %w", err)
+			}
+
+			req = req.Clone(req.Context())
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// Limiter delays requests to stay within rate limits, such as dm.RateLimiter
+type Limiter interface {
+	// Wait blocks until a request with the given method and URL is allowed or ctx is done
+	Wait(ctx context.Context, method string, url string) error
+}
+
+// ResponseObserver is implemented by the limiters adjusting themselves to the responses of the server,
+// such as dm.RateLimiter. The request is the one the limiter was waited for.
+type ResponseObserver interface {
+	ObserveResponse(req *http.Request, response *http.Response)
+}
+
+// RateLimit returns a middleware making each request wait for limiter before being sent.
+// If limiter is a ResponseObserver, the responses are reported to it.
+func RateLimit(limiter Limiter) Middleware {
+	observer, _ := limiter.(ResponseObserver)
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if err := limiter.Wait(req.Context(), req.Method, req.URL.String()); err != nil {
+				return nil, fmt.Errorf("rate limit wait: %w", err)
+			}
+
+			response, err := next.RoundTrip(req)
+			if err == nil && observer != nil {
+				observer.ObserveResponse(req, response)
+			}
+
+			return response, err
+		})
+	}
+}
+
+// RequestLimiter creates requests once the rate limits allow them, such as dm.RateLimiter
+type RequestLimiter interface {
+	HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error)
+}
+
+type requestLimiterKey struct{}
+
+// NewRequest creates a request through limiter, waiting for the rate limits, or directly when limiter is nil.
+// The limiter is kept in the context of the request, so that the Client waits for it again before retrying.
+func NewRequest(ctx context.Context, limiter RequestLimiter, method, url string, body io.Reader) (*http.Request, error) {
+	if limiter == nil {
+		return http.NewRequestWithContext(ctx, method, url, body)
+	}
+
+	req, err := limiter.HttpRequest(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), requestLimiterKey{}, limiter)), nil
+}
+
+// Do sends req with client and, if limiter is a ResponseObserver, reports the response to it.
+// The dm, md, recap and oauth packages send their requests with it, limiter being the one the request was
+// created with, so that the limiters adjusting themselves to the responses see every call.
+func Do(client *Client, limiter interface{}, req *http.Request) (*http.Response, error) {
+	response, err := client.Do(req)
+	if observer, ok := limiter.(ResponseObserver); ok && err == nil {
+		observer.ObserveResponse(req, response)
+	}
+
+	return response, err
+}
+
+// Logging returns a middleware logging the method, URL, status and duration of every request.
+// Headers are never logged, so that tokens do not end up in the logs.
+func Logging(logger *log.Logger) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			response, err := next.RoundTrip(req)
+			elapsed := time.Since(start).Round(time.Millisecond)
+
+			if err != nil {
+				logger.Printf("%s %s failed after %s: %s", req.Method, req.URL.Redacted(), elapsed, err.Error())
+				return response, err
+			}
+			logger.Printf("%s %s %d %s", req.Method, req.URL.Redacted(), response.StatusCode, elapsed)
+
+			return response, nil
+		})
+	}
+}
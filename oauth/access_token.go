@@ -0,0 +1,153 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultAudience is the audience of the access tokens issued by the Forge Authentication API
+const DefaultAudience = "https://autodesk.com"
+
+// Claims reflects the content of a Forge access token
+type Claims struct {
+	Scopes    Scopes    // The scopes the token was granted
+	ClientID  string    // The client the token was issued to
+	UserID    string    // The user who authorized the client, empty for 2-legged tokens
+	Issuer    string    // The authentication server that issued the token
+	Audience  []string  // The audiences the token is intended for
+	ExpiresAt time.Time // The expiry of the token
+	IssuedAt  time.Time // When the token was issued
+	ID        string    // The unique identifier of the token
+	Raw       string    // The encoded token
+}
+
+// RequireScope returns a ScopeError if the token was not granted all the given space-separated scopes
+func (c Claims) RequireScope(scope string) error {
+	return checkScope(scope, c.Scopes.String())
+}
+
+// scopeClaim holds the scope claim, which is either an array of scopes or a space-separated string
+type scopeClaim []string
+
+func (s *scopeClaim) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = strings.Fields(single)
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*s = multiple
+
+	return nil
+}
+
+type accessTokenClaims struct {
+	registeredClaims
+	Scope    scopeClaim `json:"scope"`
+	ClientID string     `json:"client_id"`
+	UserID   string     `json:"userid"`
+	ID       string     `json:"jti"`
+}
+
+func (c accessTokenClaims) claims(raw string) Claims {
+	scopes := make(Scopes, len(c.Scope))
+	for i, scope := range c.Scope {
+		scopes[i] = Scope(scope)
+	}
+
+	return Claims{
+		Scopes:    NewScopes(scopes...),
+		ClientID:  c.ClientID,
+		UserID:    c.UserID,
+		Issuer:    c.Issuer,
+		Audience:  c.Audience,
+		ExpiresAt: time.Unix(c.ExpiresAt, 0),
+		IssuedAt:  time.Unix(c.IssuedAt, 0),
+		ID:        c.ID,
+		Raw:       raw,
+	}
+}
+
+// ParseAccessToken decodes the claims of a Forge access token WITHOUT verifying it.
+// Use it to inspect tokens obtained from the Authentication API, e.g. to find their scopes,
+// and use an AccessTokenVerifier for tokens received from clients.
+func ParseAccessToken(accessToken string) (Claims, error) {
+	payload, err := decodeJWT(accessToken)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var claims accessTokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	return claims.claims(accessToken), nil
+}
+
+// Claims decodes the claims of the access token WITHOUT verifying it, see ParseAccessToken
+func (b Bearer) Claims() (Claims, error) {
+	return ParseAccessToken(b.AccessToken)
+}
+
+// AccessTokenVerifier checks Forge access tokens locally, without calling the introspection endpoint,
+// e.g. in a gateway receiving tokens from clients
+type AccessTokenVerifier struct {
+	Audience string        // The expected audience, no check if empty
+	Issuer   string        // The expected issuer, no check if empty
+	ClientID string        // The client the tokens must have been issued to, no check if empty
+	Keys     KeySet        // The keys the tokens are signed with
+	Leeway   time.Duration // Tolerated clock skew when checking the expiry
+	Now      func() time.Time
+}
+
+// NewAccessTokenVerifier returns a verifier of the access tokens issued by the Forge Authentication API,
+// fetching and caching the signing keys from DefaultKeySetURL
+func NewAccessTokenVerifier() *AccessTokenVerifier {
+	return &AccessTokenVerifier{
+		Audience: DefaultAudience,
+		Issuer:   DefaultIssuer,
+		Keys:     NewRemoteKeySet(DefaultKeySetURL),
+		Leeway:   time.Minute,
+		Now:      time.Now,
+	}
+}
+
+// Verify checks the signature, issuer, audience and expiry of the access token, and that it was granted
+// the given space-separated scopes, and returns its claims.
+// It returns errors wrapping ErrTokenExpired or ErrInvalidAudience, or a ScopeError when scopes are missing.
+func (v *AccessTokenVerifier) Verify(accessToken string, requiredScope string) (Claims, error) {
+	payload, err := verifyJWT(accessToken, v.Keys)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	var claims accessTokenClaims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if err = claims.check(v.Issuer, v.Audience, now, v.Leeway); err != nil {
+		return Claims{}, err
+	}
+	if len(v.ClientID) != 0 && claims.ClientID != v.ClientID {
+		return Claims{}, fmt.Errorf("%w: issued to client %q", ErrInvalidAudience, claims.ClientID)
+	}
+
+	result := claims.claims(accessToken)
+	if err = result.RequireScope(requiredScope); err != nil {
+		return Claims{}, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package oauth_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestAccessTokenVerifier_Verify(t *testing.T) {
+
+	key, keys := newSigningKey(t)
+
+	verifier := oauth.NewAccessTokenVerifier()
+	verifier.Keys = keys
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":       oauth.DefaultIssuer,
+			"aud":       oauth.DefaultAudience,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+			"iat":       time.Now().Unix(),
+			"scope":     []string{"data:write", "data:read"},
+			"client_id": "client",
+			"userid":    "user-id",
+			"jti":       "token-id",
+		}
+	}
+
+	t.Run("Valid token", func(t *testing.T) {
+		claims, err := verifier.Verify(signJWT(t, key, "key-1", validClaims()), "data:read")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if claims.ClientID != "client" || claims.UserID != "user-id" || claims.ID != "token-id" {
+			t.Errorf("Unexpected claims: %+v", claims)
+		}
+		if claims.Scopes.String() != "data:read data:write" {
+			t.Errorf("Unexpected scopes: %q", claims.Scopes.String())
+		}
+	})
+
+	t.Run("Space-separated scope", func(t *testing.T) {
+		payload := validClaims()
+		payload["scope"] = "data:read bucket:read"
+		claims, err := verifier.Verify(signJWT(t, key, "key-1", payload), "bucket:read")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if !claims.Scopes.Contains(oauth.ScopeBucketRead) {
+			t.Errorf("Unexpected scopes: %q", claims.Scopes.String())
+		}
+	})
+
+	t.Run("Expired token", func(t *testing.T) {
+		payload := validClaims()
+		payload["exp"] = time.Now().Add(-time.Hour).Unix()
+		if _, err := verifier.Verify(signJWT(t, key, "key-1", payload), "data:read"); !errors.Is(err, oauth.ErrTokenExpired) {
+			t.Errorf("Expected ErrTokenExpired, got %v", err)
+		}
+	})
+
+	t.Run("Wrong audience", func(t *testing.T) {
+		payload := validClaims()
+		payload["aud"] = "https://example.com"
+		if _, err := verifier.Verify(signJWT(t, key, "key-1", payload), "data:read"); !errors.Is(err, oauth.ErrInvalidAudience) {
+			t.Errorf("Expected ErrInvalidAudience, got %v", err)
+		}
+	})
+
+	t.Run("Wrong client", func(t *testing.T) {
+		clientVerifier := *verifier
+		clientVerifier.ClientID = "another"
+		if _, err := clientVerifier.Verify(signJWT(t, key, "key-1", validClaims()), "data:read"); !errors.Is(err, oauth.ErrInvalidAudience) {
+			t.Errorf("Expected ErrInvalidAudience, got %v", err)
+		}
+	})
+
+	t.Run("Missing scope", func(t *testing.T) {
+		_, err := verifier.Verify(signJWT(t, key, "key-1", validClaims()), "data:read bucket:delete")
+		scopeErr, ok := err.(*oauth.ScopeError)
+		if !ok {
+			t.Fatalf("Expected a ScopeError, got %v", err)
+		}
+		if len(scopeErr.Missing) != 1 || scopeErr.Missing[0] != "bucket:delete" {
+			t.Errorf("Unexpected missing scopes: %v", scopeErr.Missing)
+		}
+	})
+}
+
+func TestParseAccessToken(t *testing.T) {
+
+	key, _ := newSigningKey(t)
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	bearer := oauth.Bearer{AccessToken: signJWT(t, key, "any", map[string]interface{}{
+		"exp":       expiry.Unix(),
+		"scope":     []string{"data:read"},
+		"client_id": "client",
+	})}
+
+	claims, err := bearer.Claims()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !claims.ExpiresAt.Equal(expiry) || claims.ClientID != "client" || claims.RequireScope("data:read") != nil {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	if _, err = oauth.ParseAccessToken("opaque"); !errors.Is(err, oauth.ErrMalformedToken) {
+		t.Errorf("Expected ErrMalformedToken, got %v", err)
+	}
+}
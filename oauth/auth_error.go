@@ -0,0 +1,93 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuthError is returned when the authentication server answers with an error status.
+// It carries both the fields of the Forge error body and those of the standard OAuth error body,
+// whichever the endpoint uses.
+type AuthError struct {
+	StatusCode       int           `json:"-"`                 // The HTTP status of the response
+	DeveloperMessage string        `json:"developerMessage"`  // The Forge description of the error
+	ErrorCode        string        `json:"errorCode"`         // The Forge error code, e.g. AUTH-001
+	MoreInfo         string        `json:"more info"`         // A link to the documentation of the error
+	Code             string        `json:"error"`             // The OAuth error code, e.g. invalid_grant
+	Description      string        `json:"error_description"` // The OAuth description of the error
+	RetryAfter       time.Duration `json:"-"`                 // The delay requested by the Retry-After header, if any
+	Body             string        `json:"-"`                 // The raw response body
+}
+
+func (e *AuthError) Error() string {
+	return "[" + strconv.Itoa(e.StatusCode) + "] " + e.Body
+}
+
+// newAuthError reads the error body of the response
+func newAuthError(response *http.Response) *AuthError {
+	content, _ := ioutil.ReadAll(response.Body)
+
+	authErr := &AuthError{
+		StatusCode: response.StatusCode,
+		Body:       string(content),
+	}
+	json.Unmarshal(content, authErr)
+
+	var moreInfo struct {
+		MoreInfo string `json:"more_info"`
+	}
+	if err := json.Unmarshal(content, &moreInfo); err == nil && len(authErr.MoreInfo) == 0 {
+		authErr.MoreInfo = moreInfo.MoreInfo
+	}
+
+	if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil {
+		authErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return authErr
+}
+
+// The Forge error codes of the v1 Authentication API, which does not report OAuth error codes
+var (
+	invalidClientCodes = map[string]bool{
+		"AUTH-001": true, // The client_id specified does not have access to the api product
+		"AUTH-003": true, // The client_id and client_secret combination is not valid
+	}
+	invalidGrantCodes = map[string]bool{
+		"AUTH-004": true, // The authorization code or refresh token is expired or invalid
+	}
+)
+
+// IsInvalidGrant reports whether the authorization code or refresh token was rejected as invalid, expired or revoked.
+// The user has to go through the 3-legged flow again.
+func IsInvalidGrant(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && (authErr.Code == "invalid_grant" || invalidGrantCodes[authErr.ErrorCode])
+}
+
+// IsInvalidClient reports whether the client ID or secret was rejected
+func IsInvalidClient(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && (authErr.Code == "invalid_client" || invalidClientCodes[authErr.ErrorCode])
+}
+
+// IsInvalidScope reports whether the requested scope is invalid, whether the server rejected it
+// or it was rejected locally with ErrInvalidScope
+func IsInvalidScope(err error) bool {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.Code == "invalid_scope"
+	}
+	return errors.Is(err, ErrInvalidScope)
+}
+
+// IsRateLimited reports whether the request was rejected because too many requests were sent.
+// The AuthError RetryAfter tells how long to wait, when the server reports it.
+func IsRateLimited(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && authErr.StatusCode == http.StatusTooManyRequests
+}
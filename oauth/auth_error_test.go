@@ -0,0 +1,119 @@
+package oauth_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestAuthError(t *testing.T) {
+
+	responses := map[string]struct {
+		status int
+		body   string
+	}{
+		"v2-grant":  {http.StatusBadRequest, `{"error":"invalid_grant","error_description":"The refresh token is invalid or expired."}`},
+		"v1-grant":  {http.StatusBadRequest, `{"developerMessage":"The authorization code/refreshToken is expired or invalid.","errorCode":"AUTH-004","more info":"https://example.com/AUTH-004"}`},
+		"v1-client": {http.StatusUnauthorized, `{"developerMessage":"The client_id and client_secret combination is not valid","errorCode":"AUTH-003","more info":"https://example.com/AUTH-003"}`},
+		"v2-scope":  {http.StatusBadRequest, `{"error":"invalid_scope"}`},
+		"limited":   {http.StatusTooManyRequests, `{"developerMessage":"Too many requests"}`},
+		"shadowing": {http.StatusForbidden, `{"statusCode":0,"body":"x","retryAfter":5,"errorCode":"AUTH-010"}`},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		response := responses[r.PostForm.Get("refresh_token")]
+		if response.status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(response.status)
+		w.Write([]byte(response.body))
+	}))
+	defer server.Close()
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost/callback")
+	auth.Host = server.URL
+
+	refresh := func(refreshToken string) error {
+		_, err := auth.RefreshToken(refreshToken, "data:read")
+		// wrapped errors are classified as well
+		return fmt.Errorf("refreshing: %w", err)
+	}
+
+	t.Run("Body fields", func(t *testing.T) {
+		var authErr *oauth.AuthError
+		if !errors.As(refresh("shadowing"), &authErr) {
+			t.Fatal("Expected an AuthError")
+		}
+		if authErr.StatusCode != http.StatusForbidden || authErr.Body != responses["shadowing"].body ||
+			authErr.RetryAfter != 0 || authErr.ErrorCode != "AUTH-010" {
+			t.Errorf("Expected the body not to override the response fields, got %+v", authErr)
+		}
+	})
+
+	t.Run("Forge body", func(t *testing.T) {
+		err := refresh("v1-client")
+		var authErr *oauth.AuthError
+		if !errors.As(err, &authErr) {
+			t.Fatalf("Expected an AuthError, got %v", err)
+		}
+		if authErr.StatusCode != http.StatusUnauthorized || authErr.ErrorCode != "AUTH-003" ||
+			authErr.MoreInfo != "https://example.com/AUTH-003" || len(authErr.DeveloperMessage) == 0 {
+			t.Errorf("Unexpected error: %+v", authErr)
+		}
+		if expected := "[401] " + responses["v1-client"].body; authErr.Error() != expected {
+			t.Errorf("Expected the error message to be %q, got %q", expected, authErr.Error())
+		}
+	})
+
+	classifications := []struct {
+		refreshToken string
+		classify     func(error) bool
+	}{
+		{"v2-grant", oauth.IsInvalidGrant},
+		{"v1-grant", oauth.IsInvalidGrant},
+		{"v1-client", oauth.IsInvalidClient},
+		{"v2-scope", oauth.IsInvalidScope},
+		{"limited", oauth.IsRateLimited},
+	}
+	helpers := []func(error) bool{oauth.IsInvalidGrant, oauth.IsInvalidClient, oauth.IsInvalidScope, oauth.IsRateLimited}
+
+	for _, classification := range classifications {
+		classification := classification
+		t.Run(classification.refreshToken, func(t *testing.T) {
+			err := refresh(classification.refreshToken)
+			matches := 0
+			for _, helper := range helpers {
+				if helper(err) {
+					matches++
+				}
+			}
+			if !classification.classify(err) || matches != 1 {
+				t.Errorf("Unexpected classification of %v", err)
+			}
+		})
+	}
+
+	t.Run("Retry-After", func(t *testing.T) {
+		var authErr *oauth.AuthError
+		if !errors.As(refresh("limited"), &authErr) || authErr.RetryAfter != 30*time.Second {
+			t.Errorf("Expected the Retry-After delay to be reported, got %+v", authErr)
+		}
+	})
+
+	t.Run("Local scope validation", func(t *testing.T) {
+		_, err := auth.RefreshToken("token", "data:improvise")
+		if !oauth.IsInvalidScope(err) {
+			t.Errorf("Expected a locally rejected scope to be classified, got %v", err)
+		}
+	})
+
+	if oauth.IsInvalidGrant(errors.New("invalid_grant")) {
+		t.Error("Expected only AuthErrors to be classified")
+	}
+}
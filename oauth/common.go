@@ -1,6 +1,32 @@
 package oauth
+
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+)
+
+// AuthVersion selects the version of the Forge Authentication API used by a client
+type AuthVersion int
+
+const (
+	// AuthV1 uses the /authentication/v1 endpoints, sending the client secret in the form body
+	AuthV1 AuthVersion = iota
+	// AuthV2 uses the /authentication/v2 endpoints, authenticating the client with HTTP Basic
+	AuthV2
+)
+
+const (
+	defaultHost   = "https://developer.api.autodesk.com"
+	authV1Path    = "/authentication/v1"
+	authV2Path    = "/authentication/v2"
+	tokenEndpoint = "/token"
 )
 
 // Bearer reflects the response when acquiring a 2-legged token or in 3-legged context for exchanging the authorization
@@ -10,15 +36,25 @@ type Bearer struct {
 	ExpiresIn    int32  `json:"expires_in"`              // Access token expiration time (in seconds)
 	AccessToken  string `json:"access_token"`            // The access token
 	RefreshToken string `json:"refresh_token,omitempty"` // The refresh token used in 3-legged oauth
+	IDToken      string `json:"id_token,omitempty"`      // The OpenID Connect ID token, when the openid scope was granted
 }
 
 // AuthData reflects the data common to 2-legged and 3-legged api calls
 type AuthData struct {
-	ClientID     	 string `json:"client_id,omitempty"`
-	ClientSecret 	 string `json:"client_secret,omitempty"`
-	Host         	 string `json:"host,omitempty"`
-	AuthPath     	 string `json:"auth_path"`
-	TokenExpireTime	 time.Time `json:"expire_time,omitempty"`   // Calculated expiration time against time.Now() for 3-legged oauth
+	ClientID        string      `json:"client_id,omitempty"`
+	ClientSecret    string      `json:"client_secret,omitempty"`
+	Host            string      `json:"host,omitempty"`
+	AuthPath        string      `json:"auth_path"`
+	TokenExpireTime time.Time   `json:"expire_time,omitempty"` // Calculated expiration time against time.Now() for 3-legged oauth
+	Version         AuthVersion `json:"version,omitempty"`     // The Authentication API version AuthPath points to
+	// Credentials, if set, provides the client ID and secrets on every request instead of ClientID and ClientSecret
+	Credentials CredentialsProvider `json:"-"`
+	// Client sends the requests to the authentication server and, for the API structs built on this authenticator,
+	// to the Forge APIs. A nil Client uses the default settings, retrying with forge.DefaultRetryPolicy.
+	Client *forge.Client `json:"-"`
+	// RateLimiter, if set, delays the requests to the authentication server to stay within its rate limits,
+	// e.g. dm.DefaultRateLimiter
+	RateLimiter HttpRequestLimiter `json:"-"`
 }
 
 // ForgeAuthenticator defines an interface that allows abstraction from
@@ -27,3 +63,127 @@ type AuthData struct {
 type ForgeAuthenticator interface {
 	GetTokenWithScope(scope string) (Bearer, error)
 }
+
+func newAuthData(clientID, clientSecret string, version AuthVersion) AuthData {
+	authPath := authV1Path
+	if version == AuthV2 {
+		authPath = authV2Path
+	}
+
+	return AuthData{
+		ClientID:        clientID,
+		ClientSecret:    clientSecret,
+		Host:            defaultHost,
+		AuthPath:        authPath,
+		TokenExpireTime: time.Now(),
+		Version:         version,
+	}
+}
+
+// IsPublicClient reports whether the client has no secret, as is the case for desktop and command-line tools
+// using PKCE
+func (a AuthData) IsPublicClient() bool {
+	credentials, err := a.credentials()
+	return err == nil && len(credentials.ClientSecret) == 0
+}
+
+// credentials returns the credentials of the provider if any, otherwise ClientID and ClientSecret
+func (a AuthData) credentials() (Credentials, error) {
+	if a.Credentials != nil {
+		return a.Credentials.Credentials()
+	}
+
+	return Credentials{ClientID: a.ClientID, ClientSecret: a.ClientSecret}, nil
+}
+
+// requestToken posts the given grant to the token endpoint and decodes the received bearer.
+// In v1 the grant is sent to AuthPath+v1Endpoint, while in v2 all grants go to AuthPath+"/token".
+func (a AuthData) requestToken(v1Endpoint string, body url.Values) (bearer Bearer, err error) {
+
+	endpoint := v1Endpoint
+	if a.Version == AuthV2 {
+		endpoint = tokenEndpoint
+	}
+
+	response, err := a.postForm(a.Version, a.Host+a.AuthPath+endpoint, body)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+	err = decoder.Decode(&bearer)
+
+	return
+}
+
+// v2Path returns the path of the v2 Authentication API, for the endpoints only available in v2.
+// It is AuthPath for v2 clients. For v1 clients, the version at the end of AuthPath is replaced,
+// so that a custom prefix, e.g. of a gateway, is kept.
+func (a AuthData) v2Path() string {
+	if a.Version == AuthV2 {
+		return a.AuthPath
+	}
+	if strings.HasSuffix(a.AuthPath, authV1Path) {
+		return strings.TrimSuffix(a.AuthPath, authV1Path) + authV2Path
+	}
+
+	return authV2Path
+}
+
+// postForm sends the form to an authentication endpoint and returns the response if its status is 200.
+// In v1 the client credentials are sent in the body, while in v2 they are sent in the Authorization header.
+// Public clients only identify themselves by sending their client_id in the body.
+// If the client secret is rejected, the request is sent again with the secondary secret, if any.
+func (a AuthData) postForm(version AuthVersion, requestPath string, body url.Values) (response *http.Response, err error) {
+
+	credentials, err := a.credentials()
+	if err != nil {
+		return
+	}
+
+	response, err = postFormWithSecret(a.Client, a.RateLimiter, version, requestPath, body, credentials.ClientID, credentials.ClientSecret)
+	if IsInvalidClient(err) && len(credentials.SecondarySecret) != 0 {
+		response, err = postFormWithSecret(a.Client, a.RateLimiter, version, requestPath, body, credentials.ClientID, credentials.SecondarySecret)
+	}
+
+	return
+}
+
+func postFormWithSecret(client *forge.Client, limiter HttpRequestLimiter, version AuthVersion, requestPath string, body url.Values, clientID, clientSecret string) (response *http.Response, err error) {
+
+	useBasicAuth := version == AuthV2 && len(clientSecret) != 0
+	if !useBasicAuth {
+		body.Set("client_id", clientID)
+		if len(clientSecret) != 0 {
+			body.Set("client_secret", clientSecret)
+		}
+	}
+
+	req, err := forge.NewRequest(context.Background(), limiter, "POST",
+		requestPath,
+		bytes.NewBufferString(body.Encode()),
+	)
+
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if useBasicAuth {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	response, err = forge.Do(client, limiter, req)
+
+	if err != nil {
+		return
+	}
+
+	if response.StatusCode != http.StatusOK {
+		err = newAuthError(response)
+		response.Body.Close()
+		return
+	}
+
+	return
+}
@@ -0,0 +1,128 @@
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Credentials identify the client to the authentication server
+type Credentials struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret,omitempty"`
+	// SecondarySecret is tried when the ClientSecret is rejected, so that a secret can be rotated
+	// without downtime: the new secret is deployed as secondary, then swapped with the primary one.
+	SecondarySecret string `json:"secondary_secret,omitempty"`
+}
+
+// CredentialsProvider provides the client credentials. Authenticators with a provider read it on every request
+// to the authentication server, so that rotated secrets are used without restarting.
+//
+// Implementations must be safe for concurrent use.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// StaticCredentials is a CredentialsProvider always providing the same credentials
+type StaticCredentials Credentials
+
+// Credentials returns the static credentials
+func (c StaticCredentials) Credentials() (Credentials, error) {
+	return Credentials(c), nil
+}
+
+// The environment variables read by default by EnvCredentials
+const (
+	DefaultClientIDVariable        = "FORGE_CLIENT_ID"
+	DefaultClientSecretVariable    = "FORGE_CLIENT_SECRET"
+	DefaultSecondarySecretVariable = "FORGE_CLIENT_SECRET_SECONDARY"
+)
+
+// EnvCredentials is a CredentialsProvider reading the credentials from environment variables on every call
+type EnvCredentials struct {
+	ClientIDVariable        string
+	ClientSecretVariable    string
+	SecondarySecretVariable string
+}
+
+// NewEnvCredentials returns a provider reading FORGE_CLIENT_ID, FORGE_CLIENT_SECRET and FORGE_CLIENT_SECRET_SECONDARY
+func NewEnvCredentials() EnvCredentials {
+	return EnvCredentials{
+		ClientIDVariable:        DefaultClientIDVariable,
+		ClientSecretVariable:    DefaultClientSecretVariable,
+		SecondarySecretVariable: DefaultSecondarySecretVariable,
+	}
+}
+
+// Credentials returns the values of the environment variables, or an error if the client ID is not set
+func (e EnvCredentials) Credentials() (Credentials, error) {
+	credentials := Credentials{
+		ClientID:     os.Getenv(e.ClientIDVariable),
+		ClientSecret: os.Getenv(e.ClientSecretVariable),
+	}
+	if len(e.SecondarySecretVariable) != 0 {
+		credentials.SecondarySecret = os.Getenv(e.SecondarySecretVariable)
+	}
+
+	if len(credentials.ClientID) == 0 {
+		return Credentials{}, fmt.Errorf("the environment variable %s is not set", e.ClientIDVariable)
+	}
+
+	return credentials, nil
+}
+
+// FileCredentials is a CredentialsProvider reading the credentials from a JSON file, such as a mounted secret,
+// with the fields client_id, client_secret and secondary_secret.
+// The file is watched: it is read again whenever its modification time or size changes.
+type FileCredentials struct {
+	Path string
+
+	mutex       sync.Mutex
+	credentials Credentials
+	modTime     time.Time
+	size        int64
+}
+
+// NewFileCredentials returns a provider reading the credentials from the JSON file at path
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{Path: path}
+}
+
+// Credentials returns the content of the file, read again if it changed since the previous call.
+// If the changed file cannot be read or parsed, the error is returned and the next call tries again.
+func (f *FileCredentials) Credentials() (Credentials, error) {
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.credentials, nil
+	}
+
+	content, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var credentials Credentials
+	if err = json.Unmarshal(content, &credentials); err != nil {
+		return Credentials{}, fmt.Errorf("invalid credentials file %s: %w", f.Path, err)
+	}
+	if len(credentials.ClientID) == 0 {
+		return Credentials{}, errors.New("no client_id in the credentials file " + f.Path)
+	}
+
+	f.credentials = credentials
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+
+	return credentials, nil
+}
@@ -0,0 +1,132 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// secretServer only accepts the given client secret, sent with HTTP Basic
+func secretServer(secret string, attempts *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(attempts, 1)
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "client" || clientSecret != secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "access", ExpiresIn: 3599})
+	}))
+}
+
+func TestCredentialsProvider_SecondarySecret(t *testing.T) {
+
+	var attempts int32
+	server := secretServer("new", &attempts)
+	defer server.Close()
+
+	auth := oauth.NewTwoLeggedClientWithProvider(oauth.StaticCredentials{
+		ClientID:        "client",
+		ClientSecret:    "old",
+		SecondarySecret: "new",
+	})
+	auth.Host = server.URL
+
+	bearer, err := auth.Authenticate("data:read")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if bearer.AccessToken != "access" || atomic.LoadInt32(&attempts) != 2 {
+		t.Errorf("Expected the secondary secret to be used after the primary one, got %d attempts", attempts)
+	}
+
+	t.Run("No secondary secret", func(t *testing.T) {
+		auth.Credentials = oauth.StaticCredentials{ClientID: "client", ClientSecret: "old"}
+		auth.Cache = nil
+		if _, err := auth.Authenticate("data:read"); !oauth.IsInvalidClient(err) {
+			t.Errorf("Expected the rejected secret to be reported, got %v", err)
+		}
+	})
+}
+
+func TestFileCredentials(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "credentials.json")
+	write := func(secret string, modTime time.Time) {
+		content, _ := json.Marshal(oauth.Credentials{ClientID: "client", ClientSecret: secret})
+		if err := ioutil.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	var attempts int32
+	server := secretServer("rotated", &attempts)
+	defer server.Close()
+
+	write("initial", time.Now().Add(-time.Hour))
+
+	auth := oauth.NewTwoLeggedClientWithProvider(oauth.NewFileCredentials(path))
+	auth.Host = server.URL
+
+	if _, err = auth.Authenticate("data:read"); !oauth.IsInvalidClient(err) {
+		t.Errorf("Expected the initial secret to be rejected, got %v", err)
+	}
+
+	write("rotated", time.Now())
+
+	if _, err = auth.Authenticate("data:read"); err != nil {
+		t.Errorf("Expected the rotated secret to be read from the file, got %v", err)
+	}
+
+	t.Run("Invalid file", func(t *testing.T) {
+		if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+			t.Fatal(err.Error())
+		}
+		if _, err := oauth.NewFileCredentials(path).Credentials(); err == nil {
+			t.Error("Expected an invalid file to be reported")
+		}
+	})
+}
+
+func TestEnvCredentials(t *testing.T) {
+
+	provider := oauth.EnvCredentials{
+		ClientIDVariable:     "FORGE_TEST_CLIENT_ID",
+		ClientSecretVariable: "FORGE_TEST_CLIENT_SECRET",
+	}
+	defer os.Unsetenv(provider.ClientIDVariable)
+	defer os.Unsetenv(provider.ClientSecretVariable)
+
+	if _, err := provider.Credentials(); err == nil {
+		t.Error("Expected a missing client ID to be reported")
+	}
+
+	os.Setenv(provider.ClientIDVariable, "client")
+	os.Setenv(provider.ClientSecretVariable, "secret")
+
+	credentials, err := provider.Credentials()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if credentials.ClientID != "client" || credentials.ClientSecret != "secret" {
+		t.Errorf("Unexpected credentials: %+v", credentials)
+	}
+}
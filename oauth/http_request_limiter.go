@@ -0,0 +1,11 @@
+package oauth
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+type HttpRequestLimiter interface {
+	HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error)
+}
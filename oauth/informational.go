@@ -1,11 +1,11 @@
 package oauth
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
-	"io/ioutil"
 	"net/http"
-	"strconv"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
 // UserProfile reflects the response received when query the profile of an authorizing end user in a 3-legged context
@@ -25,28 +25,56 @@ type UserProfile struct {
 	ProfileImages interface{} `json:"profileImages"`
 }
 
+// UserInfo reflects the claims returned by the OpenID Connect userinfo endpoint about an authorizing end user
+type UserInfo struct {
+	Subject           string `json:"sub"`                // The user ID
+	Name              string `json:"name"`               // The full name of the user
+	GivenName         string `json:"given_name"`         // The user’s first name
+	FamilyName        string `json:"family_name"`        // The user’s last name
+	PreferredUsername string `json:"preferred_username"` // The username chosen by the user
+	Email             string `json:"email"`              // The user’s email address
+	EmailVerified     bool   `json:"email_verified"`     // true if the user’s email address has been verified
+	Picture           string `json:"picture"`            // The URL of the profile image of the user
+	Locale            string `json:"locale"`             // The language of the user
+}
+
 // Information struct is holding the host and path used when making queries
 // for profile of an authorizing end user in a 3-legged context
 type Information struct {
 	Host        string `json:"host,omitempty"`
 	ProfilePath string `json:"profile_path"`
+	UserInfoURL string `json:"userinfo_url,omitempty"` // The OpenID Connect userinfo endpoint
+	// Client sends the requests, with the default settings if nil
+	Client *forge.Client `json:"-"`
+	// RateLimiter, if set, delays the requests to stay within the rate limits
+	RateLimiter HttpRequestLimiter `json:"-"`
 }
 
 // NewInformationQuerier returns an Informational API accessor with default host and profilePath
 func NewInformationQuerier() Information {
 	return Information{
-		"https://developer.api.autodesk.com",
-		"/userprofile/v1/users/@me",
+		Host:        "https://developer.api.autodesk.com",
+		ProfilePath: "/userprofile/v1/users/@me",
+		UserInfoURL: "https://api.userprofile.autodesk.com/userinfo",
 	}
 }
 
 //AboutMe is used to get the profile of an authorizing end user, given the token obtained via 3-legged OAuth flow
 func (a Information) AboutMe(token string) (profile UserProfile, err error) {
+	err = a.get(a.Host+a.ProfilePath, token, &profile)
+	return
+}
+
+// UserInfo is used to get the OpenID Connect claims about an authorizing end user,
+// given a 3-legged token obtained with the openid scope
+func (a Information) UserInfo(token string) (info UserInfo, err error) {
+	err = a.get(a.UserInfoURL, token, &info)
+	return
+}
 
-	requestPath := a.Host + a.ProfilePath
-	task := http.Client{}
+func (a Information) get(requestPath string, token string, result interface{}) (err error) {
 
-	req, err := http.NewRequest("GET",
+	req, err := forge.NewRequest(context.Background(), a.RateLimiter, "GET",
 		requestPath,
 		nil,
 	)
@@ -56,7 +84,7 @@ func (a Information) AboutMe(token string) (profile UserProfile, err error) {
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(a.Client, a.RateLimiter, req)
 
 	if err != nil {
 		return
@@ -64,13 +92,12 @@ func (a Information) AboutMe(token string) (profile UserProfile, err error) {
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = newAuthError(response)
 		return
 	}
 
 	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&profile)
+	err = decoder.Decode(result)
 
 	return
 }
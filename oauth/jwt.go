@@ -0,0 +1,322 @@
+package oauth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+)
+
+var (
+	// ErrMalformedToken is returned when a token is not a well-formed JSON Web Token
+	ErrMalformedToken = errors.New("malformed token")
+	// ErrInvalidSignature is returned when the signature of a token does not match the key it claims to be signed with
+	ErrInvalidSignature = errors.New("invalid token signature")
+	// ErrUnknownKey is returned when a token is signed with a key that is not in the key set
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+	// ErrTokenExpired is returned when a token is used after its expiry
+	ErrTokenExpired = errors.New("token expired")
+	// ErrInvalidIssuer is returned when a token was not issued by the expected authentication server
+	ErrInvalidIssuer = errors.New("invalid token issuer")
+	// ErrInvalidAudience is returned when a token was not issued for this client
+	ErrInvalidAudience = errors.New("invalid token audience")
+)
+
+// DefaultKeySetURL is where the Forge Authentication API publishes the keys signing its tokens
+const DefaultKeySetURL = "https://developer.api.autodesk.com/authentication/v2/keys"
+
+// KeySet provides the public keys used to verify the signature of tokens
+type KeySet interface {
+	// PublicKey returns the key with the given ID, or an error wrapping ErrUnknownKey if there is none
+	PublicKey(keyID string) (*rsa.PublicKey, error)
+}
+
+// JSONWebKey reflects a public RSA key as published in a JSON Web Key Set
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Modulus   string `json:"n"` // base64url-encoded
+	Exponent  string `json:"e"` // base64url-encoded
+}
+
+// JSONWebKeySet is a static KeySet, e.g. parsed from a file or built in tests
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey returns the JSON Web Key of the given RSA public key
+func NewJSONWebKey(keyID string, key *rsa.PublicKey) JSONWebKey {
+	return JSONWebKey{
+		KeyType:   "RSA",
+		KeyID:     keyID,
+		Use:       "sig",
+		Algorithm: "RS256",
+		Modulus:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// PublicKey returns the RSA key with the given ID
+func (s JSONWebKeySet) PublicKey(keyID string) (*rsa.PublicKey, error) {
+	for _, key := range s.Keys {
+		if key.KeyID == keyID && key.KeyType == "RSA" {
+			return key.rsaPublicKey()
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownKey, keyID)
+}
+
+func (k JSONWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.Modulus, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %q: %w", k.KeyID, err)
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(k.Exponent, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of key %q: %w", k.KeyID, err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
+
+// RemoteKeySet is a KeySet fetching the keys from a JSON Web Key Set URL.
+// The keys are cached and fetched again when a token refers to an unknown key, which happens when keys are rotated.
+// Concurrent lookups share a single fetch, and a failed fetch is reported for a few seconds before being retried.
+type RemoteKeySet struct {
+	URL string
+	// MinRefreshInterval limits how often the keys are fetched again, so that tokens with random key IDs
+	// cannot make the client flood the server
+	MinRefreshInterval time.Duration
+	// Client fetches the keys, with the default settings if nil
+	Client *forge.Client
+
+	mutex     sync.Mutex // Only guards the fields below, never held while fetching
+	keys      JSONWebKeySet
+	fetchedAt time.Time
+	failure   error     // The error of the last fetch, if it failed
+	failedAt  time.Time // When the last fetch failed
+	fetching  *keyFetch
+}
+
+// keyFetch is a fetch in progress, awaited by all the lookups needing it
+type keyFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// keySetRetryInterval is how long the error of a failed fetch is returned before the keys are fetched again
+const keySetRetryInterval = 5 * time.Second
+
+// NewRemoteKeySet returns a KeySet fetching the keys from url, usually DefaultKeySetURL
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		URL:                url,
+		MinRefreshInterval: time.Minute,
+	}
+}
+
+// PublicKey returns the key with the given ID, fetching the keys again if it is not among the cached ones
+func (s *RemoteKeySet) PublicKey(keyID string) (*rsa.PublicKey, error) {
+	s.mutex.Lock()
+
+	key, err := s.keys.PublicKey(keyID)
+	if err == nil {
+		s.mutex.Unlock()
+		return key, nil
+	}
+	if s.failure != nil && time.Since(s.failedAt) < keySetRetryInterval {
+		err = s.failure
+		s.mutex.Unlock()
+		return nil, err
+	}
+	if s.fetching == nil && !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < s.MinRefreshInterval {
+		s.mutex.Unlock()
+		return nil, err
+	}
+
+	fetch := s.fetching
+	if fetch == nil {
+		fetch = &keyFetch{done: make(chan struct{})}
+		s.fetching = fetch
+		s.mutex.Unlock()
+		s.fetch(fetch)
+	} else {
+		s.mutex.Unlock()
+		<-fetch.done
+	}
+
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.keys.PublicKey(keyID)
+}
+
+// fetch gets the keys without holding the lock, then stores them or the failure
+func (s *RemoteKeySet) fetch(fetch *keyFetch) {
+	keys, err := s.download()
+
+	s.mutex.Lock()
+	if err != nil {
+		s.failure = err
+		s.failedAt = time.Now()
+	} else {
+		s.keys = keys
+		s.fetchedAt = time.Now()
+		s.failure = nil
+	}
+	s.fetching = nil
+	s.mutex.Unlock()
+
+	fetch.err = err
+	close(fetch.done)
+}
+
+func (s *RemoteKeySet) download() (keys JSONWebKeySet, err error) {
+	response, err := s.Client.Get(s.URL)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		content, _ := ioutil.ReadAll(response.Body)
+		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		return
+	}
+
+	err = json.NewDecoder(response.Body).Decode(&keys)
+
+	return
+}
+
+// audience holds the aud claim, which is either a single string or an array of strings
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, item := range a {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// registeredClaims holds the standard claims checked by the verifiers
+type registeredClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+}
+
+// check verifies the issuer, the audience when one is expected, and the expiry, allowing for leeway of clock skew
+func (c registeredClaims) check(issuer, expectedAudience string, now time.Time, leeway time.Duration) error {
+	if len(issuer) != 0 && c.Issuer != issuer {
+		return fmt.Errorf("%w: %q", ErrInvalidIssuer, c.Issuer)
+	}
+	if len(expectedAudience) != 0 && !c.Audience.contains(expectedAudience) {
+		return fmt.Errorf("%w: %v", ErrInvalidAudience, []string(c.Audience))
+	}
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: no expiry", ErrMalformedToken)
+	}
+	if expiry := time.Unix(c.ExpiresAt, 0); now.After(expiry.Add(leeway)) {
+		return fmt.Errorf("%w at %s", ErrTokenExpired, expiry.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// verifyJWT checks the RS256 signature of the token against keys and returns its decoded payload
+func verifyJWT(token string, keys KeySet) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	content, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err = json.Unmarshal(content, &header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, header.Algorithm)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	key, err := keys.PublicKey(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	return decodeJWT(token)
+}
+
+// decodeJWT returns the decoded payload of the token without verifying its signature
+func decodeJWT(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformedToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedToken, err)
+	}
+
+	return payload, nil
+}
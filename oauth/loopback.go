@@ -0,0 +1,202 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// ErrStateMismatch is returned when the state passed back to the callback is not the one sent to Authorize,
+// which means the callback was not triggered by the authorization started by this client.
+// LoginWithLoopback answers such callbacks with this error and keeps waiting for the right one.
+var ErrStateMismatch = errors.New("the state received on the callback does not match the one of the authorization request")
+
+// ErrAuthorizationFailed is returned when the callback reports an error instead of a code, e.g. when the user denied access
+var ErrAuthorizationFailed = errors.New("authorization failed")
+
+// LoopbackOptions configures LoginWithLoopback
+type LoopbackOptions struct {
+	// OpenURL is called with the authorization URL the user has to visit, e.g. to open it in a browser.
+	// By default the URL is printed to Output.
+	OpenURL func(authorizationURL string) error
+	// Output is where the authorization URL is printed when OpenURL is not set. Defaults to os.Stdout.
+	Output io.Writer
+	// Timeout limits how long to wait for the user to authorize the application. No limit other than the context if 0.
+	Timeout time.Duration
+}
+
+type loopbackResult struct {
+	code string
+	err  error
+}
+
+// LoginWithLoopback runs the 3-legged flow for command-line tools: it starts a temporary HTTP listener on the
+// loopback address of auth.RedirectURI, has the user visit the URL built by Authorize, waits for the callback,
+// checks the state and exchanges the received code for a token refreshed with the requested scope.
+//
+// The RedirectURI must point to localhost, 127.0.0.1 or [::1]. If it has no port or port 0, a free port is chosen,
+// which only works if the authorization server accepts any loopback port.
+// Public clients (without a client secret) use PKCE.
+func LoginWithLoopback(ctx context.Context, auth ThreeLeggedAuth, scope string, options LoopbackOptions) (*RefreshableToken, error) {
+
+	redirect, err := url.Parse(auth.RedirectURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	if host := redirect.Hostname(); host != "localhost" && host != "127.0.0.1" && host != "::1" {
+		return nil, fmt.Errorf("the redirect URI %s is not a loopback address", auth.RedirectURI)
+	}
+
+	address := redirect.Host
+	if redirect.Port() == "" {
+		address = net.JoinHostPort(redirect.Hostname(), "0")
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen for the callback: %w", err)
+	}
+	defer listener.Close()
+
+	if redirect.Port() == "" || redirect.Port() == "0" {
+		_, port, _ := net.SplitHostPort(listener.Addr().String())
+		redirect.Host = net.JoinHostPort(redirect.Hostname(), port)
+		auth.RedirectURI = redirect.String()
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	var authorizationURL string
+	var pkce PKCE
+	if auth.IsPublicClient() {
+		if pkce, err = NewPKCE(); err != nil {
+			return nil, err
+		}
+		authorizationURL, err = auth.AuthorizeWithPKCE(scope, state, pkce)
+	} else {
+		authorizationURL, err = auth.Authorize(scope, state)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan loopbackResult, 1)
+	server := &http.Server{Handler: loopbackHandler(redirect.Path, state, results)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	if err = openURL(options, authorizationURL); err != nil {
+		return nil, err
+	}
+
+	var result loopbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for the authorization: %w", ctx.Err())
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	var bearer Bearer
+	if auth.IsPublicClient() {
+		bearer, err = auth.GetTokenWithVerifier(result.code, pkce.Verifier)
+	} else {
+		bearer, err = auth.GetToken(result.code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	expiryTime := time.Now().Add(time.Second * time.Duration(bearer.ExpiresIn))
+
+	return NewRefreshableTokenWithScope(&bearer, expiryTime, scopesOf(scope)), nil
+}
+
+// loopbackHandler handles the callback, reporting the received code or the error to results
+func loopbackHandler(path string, state string, results chan<- loopbackResult) http.Handler {
+	if len(path) == 0 {
+		path = "/"
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		if query.Get("state") != state {
+			// not the callback of this login, e.g. a request forged by another page: keep waiting for it
+			http.Error(w, "Login failed: "+ErrStateMismatch.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var result loopbackResult
+		switch {
+		case len(query.Get("error")) != 0:
+			result.err = fmt.Errorf("%w: %s %s", ErrAuthorizationFailed, query.Get("error"), query.Get("error_description"))
+		case len(query.Get("code")) == 0:
+			result.err = errors.New("no authorization code received on the callback")
+		default:
+			result.code = query.Get("code")
+		}
+
+		select {
+		case results <- result:
+		default:
+			// a result was already received, the login is over
+			http.Error(w, "This login has already completed.", http.StatusGone)
+			return
+		}
+
+		if result.err != nil {
+			http.Error(w, "Login failed: "+result.err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "Login succeeded. You can close this window and return to the application.")
+	})
+}
+
+func openURL(options LoopbackOptions, authorizationURL string) error {
+	if options.OpenURL != nil {
+		return options.OpenURL(authorizationURL)
+	}
+
+	output := options.Output
+	if output == nil {
+		output = os.Stdout
+	}
+
+	_, err := fmt.Fprintf(output, "Open the following URL in your browser to log in:\n%s\n", authorizationURL)
+
+	return err
+}
+
+// randomState returns an unguessable value for the state parameter
+func randomState() (string, error) {
+	buffer := make([]byte, 16)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buffer), nil
+}
@@ -0,0 +1,149 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// fakeAuthorizationServer approves every authorization request right away by redirecting to the callback,
+// passing back the state altered by tamper
+func fakeAuthorizationServer(t *testing.T, tamper func(state string) string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authentication/v2/authorize":
+			query := r.URL.Query()
+			if query.Get("code_challenge") == "" {
+				t.Error("Expected a public client to use PKCE")
+			}
+			callback, _ := url.Parse(query.Get("redirect_uri"))
+			callback.RawQuery = url.Values{
+				"code":  {"the-code"},
+				"state": {tamper(query.Get("state"))},
+			}.Encode()
+			http.Redirect(w, r, callback.String(), http.StatusFound)
+		case "/authentication/v2/token":
+			r.ParseForm()
+			if r.PostForm.Get("code") != "the-code" || r.PostForm.Get("code_verifier") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3599})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// visit simulates the browser of the user
+func visit(authorizationURL string) error {
+	go func() {
+		if response, err := http.Get(authorizationURL); err == nil {
+			response.Body.Close()
+		}
+	}()
+	return nil
+}
+
+func TestLoginWithLoopback(t *testing.T) {
+
+	server := fakeAuthorizationServer(t, func(state string) string { return state })
+	defer server.Close()
+
+	auth := oauth.NewPublicThreeLeggedClient("client", "http://127.0.0.1:0/callback")
+	auth.Host = server.URL
+
+	token, err := oauth.LoginWithLoopback(context.Background(), auth, "data:read data:write", oauth.LoopbackOptions{
+		OpenURL: visit,
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if token.Bearer().AccessToken != "access" || token.Bearer().RefreshToken != "refresh" {
+		t.Errorf("Unexpected token: %+v", token.Bearer())
+	}
+	if token.GrantedScope() != "data:read data:write" {
+		t.Errorf("Expected the requested scope to be kept, got %s", token.GrantedScope())
+	}
+	if !token.ExpiryTime().After(time.Now()) {
+		t.Error("Expected the token to be valid")
+	}
+}
+
+func TestLoginWithLoopback_StateMismatch(t *testing.T) {
+
+	server := fakeAuthorizationServer(t, func(state string) string { return state })
+	defer server.Close()
+
+	auth := oauth.NewPublicThreeLeggedClient("client", "http://127.0.0.1:0/callback")
+	auth.Host = server.URL
+
+	// another page calls the callback with an error and the wrong state before the user logs in
+	var forged int
+	token, err := oauth.LoginWithLoopback(context.Background(), auth, "data:read", oauth.LoopbackOptions{
+		OpenURL: func(authorizationURL string) error {
+			authorization, _ := url.Parse(authorizationURL)
+			callback, _ := url.Parse(authorization.Query().Get("redirect_uri"))
+			callback.RawQuery = url.Values{"error": {"access_denied"}, "state": {"forged"}}.Encode()
+
+			response, err := http.Get(callback.String())
+			if err != nil {
+				return err
+			}
+			response.Body.Close()
+			forged = response.StatusCode
+
+			return visit(authorizationURL)
+		},
+		Timeout: 5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Expected the login to ignore the callback with the wrong state, got %s", err.Error())
+	}
+
+	if forged != http.StatusBadRequest {
+		t.Errorf("Expected the callback with the wrong state to be answered with 400, got %d", forged)
+	}
+	if token.Bearer().AccessToken != "access" {
+		t.Errorf("Unexpected token: %+v", token.Bearer())
+	}
+}
+
+func TestLoginWithLoopback_Timeout(t *testing.T) {
+
+	auth := oauth.NewPublicThreeLeggedClient("client", "http://127.0.0.1:0/callback")
+
+	start := time.Now()
+	_, err := oauth.LoginWithLoopback(context.Background(), auth, "data:read", oauth.LoopbackOptions{
+		OpenURL: func(string) error { return nil },
+		Timeout: 50 * time.Millisecond,
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the login to time out, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("The timeout was not respected")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = oauth.LoginWithLoopback(ctx, auth, "data:read", oauth.LoopbackOptions{
+		OpenURL: func(string) error { return nil },
+	}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the login to be cancelled, got %v", err)
+	}
+
+	auth.RedirectURI = "http://example.com/callback"
+	if _, err = oauth.LoginWithLoopback(ctx, auth, "data:read", oauth.LoopbackOptions{}); err == nil {
+		t.Error("Expected a non-loopback redirect URI to be rejected")
+	}
+}
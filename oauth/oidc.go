@@ -0,0 +1,163 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ErrInvalidNonce is returned when the nonce of an ID token is not the one sent to AuthorizeWithNonce,
+// which means the token was not issued for the login started by this client
+var ErrInvalidNonce = errors.New("invalid ID token nonce")
+
+// DefaultIssuer is the issuer of the tokens of the Forge Authentication API
+const DefaultIssuer = "https://developer.api.autodesk.com"
+
+// IDToken reflects the claims of an OpenID Connect ID token, identifying the user who logged in
+type IDToken struct {
+	Issuer        string    `json:"iss"`
+	Subject       string    `json:"sub"` // The user ID
+	Audience      []string  `json:"aud"` // The client IDs the token was issued for
+	ExpiresAt     time.Time `json:"exp"`
+	IssuedAt      time.Time `json:"iat"`
+	Nonce         string    `json:"nonce,omitempty"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	Raw           string    `json:"-"` // The encoded token
+}
+
+// idTokenClaims is the JSON form of IDToken, with the times in seconds since the Unix epoch
+// and the audience being a string or an array as in the claims of the token
+type idTokenClaims struct {
+	idToken
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	IssuedAt  int64    `json:"iat"`
+}
+
+type idToken IDToken
+
+// MarshalJSON encodes the token as its claims
+func (t IDToken) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idTokenClaims{
+		idToken:   idToken(t),
+		Audience:  t.Audience,
+		ExpiresAt: numericDate(t.ExpiresAt),
+		IssuedAt:  numericDate(t.IssuedAt),
+	})
+}
+
+// UnmarshalJSON decodes the claims of a token, without verifying them
+func (t *IDToken) UnmarshalJSON(data []byte) error {
+	var claims idTokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil {
+		return err
+	}
+
+	*t = IDToken(claims.idToken)
+	t.Audience = claims.Audience
+	t.ExpiresAt = fromNumericDate(claims.ExpiresAt)
+	t.IssuedAt = fromNumericDate(claims.IssuedAt)
+
+	return nil
+}
+
+func numericDate(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func fromNumericDate(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// IDTokenVerifier parses and verifies the ID token returned along with the access token when the openid scope is granted
+type IDTokenVerifier struct {
+	ClientID string        // The expected audience
+	Issuer   string        // The expected issuer
+	Keys     KeySet        // The keys the tokens are signed with
+	Leeway   time.Duration // Tolerated clock skew when checking the expiry
+	Now      func() time.Time
+}
+
+// NewIDTokenVerifier returns a verifier of the ID tokens issued to clientID by the Forge Authentication API,
+// fetching the signing keys from DefaultKeySetURL
+func NewIDTokenVerifier(clientID string) *IDTokenVerifier {
+	return &IDTokenVerifier{
+		ClientID: clientID,
+		Issuer:   DefaultIssuer,
+		Keys:     NewRemoteKeySet(DefaultKeySetURL),
+		Leeway:   time.Minute,
+		Now:      time.Now,
+	}
+}
+
+// Verify checks the signature, issuer, audience, expiry and nonce of the raw ID token and returns its claims.
+// The nonce must be the one passed to AuthorizeWithNonce.
+func (v *IDTokenVerifier) Verify(rawIDToken string, nonce string) (token IDToken, err error) {
+	payload, err := verifyJWT(rawIDToken, v.Keys)
+	if err != nil {
+		return
+	}
+
+	var claims struct {
+		registeredClaims
+		Nonce         string `json:"nonce"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		err = fmt.Errorf("%w: %v", ErrMalformedToken, err)
+		return
+	}
+
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if err = claims.check(v.Issuer, v.ClientID, now, v.Leeway); err != nil {
+		return
+	}
+	if !hmac.Equal([]byte(claims.Nonce), []byte(nonce)) {
+		err = ErrInvalidNonce
+		return
+	}
+
+	token = IDToken{
+		Issuer:        claims.Issuer,
+		Subject:       claims.Subject,
+		Audience:      claims.Audience,
+		ExpiresAt:     fromNumericDate(claims.ExpiresAt),
+		IssuedAt:      fromNumericDate(claims.IssuedAt),
+		Nonce:         claims.Nonce,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Raw:           rawIDToken,
+	}
+
+	return
+}
+
+// NewNonce returns an unguessable value to be passed to AuthorizeWithNonce and then to IDTokenVerifier.Verify
+func NewNonce() (string, error) {
+	return randomState()
+}
+
+// AuthorizeWithNonce works as Authorize, additionally requesting the openid scope and passing the nonce,
+// so that the token exchange also returns an ID token bound to this login in Bearer.IDToken
+func (a ThreeLeggedAuth) AuthorizeWithNonce(scope string, state string, nonce string) (string, error) {
+	scopes := scopesOf(scope).Union(NewScopes(ScopeOpenID))
+
+	return a.authorizeURL(scopes.String(), state, url.Values{"nonce": {nonce}})
+}
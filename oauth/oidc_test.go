@@ -0,0 +1,308 @@
+package oauth_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// signJWT returns an RS256 token with the given claims, signed by key and referring to it as keyID
+func signJWT(t *testing.T, key *rsa.PrivateKey, keyID string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": keyID})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newSigningKey(t *testing.T) (*rsa.PrivateKey, oauth.JSONWebKeySet) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return key, oauth.JSONWebKeySet{Keys: []oauth.JSONWebKey{oauth.NewJSONWebKey("key-1", &key.PublicKey)}}
+}
+
+func TestIDTokenVerifier_Verify(t *testing.T) {
+
+	key, keys := newSigningKey(t)
+	otherKey, _ := newSigningKey(t)
+
+	verifier := oauth.NewIDTokenVerifier("client")
+	verifier.Keys = keys
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   oauth.DefaultIssuer,
+			"sub":   "user-id",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": "the-nonce",
+			"email": "user@example.com",
+		}
+	}
+
+	t.Run("Valid token", func(t *testing.T) {
+		raw := signJWT(t, key, "key-1", validClaims())
+		token, err := verifier.Verify(raw, "the-nonce")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if token.Subject != "user-id" || token.Email != "user@example.com" || token.Raw != raw {
+			t.Errorf("Unexpected claims: %+v", token)
+		}
+		if token.ExpiresAt.Before(time.Now()) {
+			t.Errorf("Unexpected expiry: %s", token.ExpiresAt)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		claims := validClaims()
+		token, err := verifier.Verify(signJWT(t, key, "key-1", claims), "the-nonce")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		content, err := json.Marshal(token)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		var encoded map[string]interface{}
+		json.Unmarshal(content, &encoded)
+		if encoded["exp"] != float64(claims["exp"].(int64)) || encoded["iat"] != float64(claims["iat"].(int64)) || encoded["sub"] != "user-id" {
+			t.Errorf("Expected the token to be encoded as its claims, got %s", content)
+		}
+
+		var decoded oauth.IDToken
+		if err := json.Unmarshal(content, &decoded); err != nil {
+			t.Fatal(err.Error())
+		}
+		decoded.Raw = token.Raw
+		if !reflect.DeepEqual(decoded, token) {
+			t.Errorf("Expected the decoded token to match, got %+v instead of %+v", decoded, token)
+		}
+
+		// the claims of a token can be decoded as well, with a single audience
+		payload, _ := json.Marshal(claims)
+		if err := json.Unmarshal(payload, &decoded); err != nil || decoded.Audience[0] != "client" || !decoded.ExpiresAt.Equal(token.ExpiresAt) {
+			t.Errorf("Expected the claims to be decoded, got %+v, %v", decoded, err)
+		}
+	})
+
+	t.Run("Audience as an array", func(t *testing.T) {
+		claims := validClaims()
+		claims["aud"] = []string{"another", "client"}
+		if _, err := verifier.Verify(signJWT(t, key, "key-1", claims), "the-nonce"); err != nil {
+			t.Error(err.Error())
+		}
+	})
+
+	failures := []struct {
+		name     string
+		tamper   func(claims map[string]interface{})
+		signer   *rsa.PrivateKey
+		keyID    string
+		nonce    string
+		expected error
+	}{
+		{"Expired", func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, key, "key-1", "the-nonce", oauth.ErrTokenExpired},
+		{"Other issuer", func(c map[string]interface{}) { c["iss"] = "https://example.com" }, key, "key-1", "the-nonce", oauth.ErrInvalidIssuer},
+		{"Other audience", func(c map[string]interface{}) { c["aud"] = "another" }, key, "key-1", "the-nonce", oauth.ErrInvalidAudience},
+		{"Other nonce", func(c map[string]interface{}) {}, key, "key-1", "another-nonce", oauth.ErrInvalidNonce},
+		{"Other signing key", func(c map[string]interface{}) {}, otherKey, "key-1", "the-nonce", oauth.ErrInvalidSignature},
+		{"Unknown key", func(c map[string]interface{}) {}, key, "key-2", "the-nonce", oauth.ErrUnknownKey},
+	}
+	for _, failure := range failures {
+		failure := failure
+		t.Run(failure.name, func(t *testing.T) {
+			claims := validClaims()
+			failure.tamper(claims)
+			_, err := verifier.Verify(signJWT(t, failure.signer, failure.keyID, claims), failure.nonce)
+			if !errors.Is(err, failure.expected) {
+				t.Errorf("Expected %v, got %v", failure.expected, err)
+			}
+		})
+	}
+
+	t.Run("Malformed token", func(t *testing.T) {
+		if _, err := verifier.Verify("not.a-token", "the-nonce"); !errors.Is(err, oauth.ErrMalformedToken) {
+			t.Errorf("Expected ErrMalformedToken, got %v", err)
+		}
+	})
+}
+
+func TestRemoteKeySet(t *testing.T) {
+
+	key, keys := newSigningKey(t)
+
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(keys)
+	}))
+	defer server.Close()
+
+	remote := oauth.NewRemoteKeySet(server.URL)
+
+	publicKey, err := remote.PublicKey("key-1")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if publicKey.N.Cmp(key.N) != 0 || publicKey.E != key.E {
+		t.Error("Expected the fetched key to match the signing key")
+	}
+
+	remote.PublicKey("key-1")
+	if _, err = remote.PublicKey("key-2"); !errors.Is(err, oauth.ErrUnknownKey) {
+		t.Errorf("Expected ErrUnknownKey, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the keys to be fetched once within the refresh interval, got %d", fetches)
+	}
+
+	t.Run("Concurrent lookups", func(t *testing.T) {
+		var fetches int32
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&fetches, 1) > 1 {
+				<-release
+			}
+			json.NewEncoder(w).Encode(keys)
+		}))
+		defer server.Close()
+
+		remote := oauth.NewRemoteKeySet(server.URL)
+		remote.MinRefreshInterval = 0
+		if _, err := remote.PublicKey("key-1"); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		// the lookups of an unknown key share one fetch, during which the cached keys are still available
+		var wait sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wait.Add(1)
+			go func() {
+				defer wait.Done()
+				if _, err := remote.PublicKey("key-2"); !errors.Is(err, oauth.ErrUnknownKey) {
+					t.Errorf("Expected ErrUnknownKey, got %v", err)
+				}
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		found := make(chan error)
+		go func() {
+			_, err := remote.PublicKey("key-1")
+			found <- err
+		}()
+		select {
+		case err := <-found:
+			if err != nil {
+				t.Error(err.Error())
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected the cached key to be returned while the keys are fetched")
+		}
+
+		close(release)
+		wait.Wait()
+		if n := atomic.LoadInt32(&fetches); n != 2 {
+			t.Errorf("Expected the concurrent lookups to share one fetch, got %d fetches", n)
+		}
+	})
+
+	t.Run("Failed fetch", func(t *testing.T) {
+		fetches := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		remote := oauth.NewRemoteKeySet(server.URL)
+		remote.Client = forge.NewClient(forge.WithMiddleware(forge.Retry(forge.NoRetry)))
+		for i := 0; i < 3; i++ {
+			if _, err := remote.PublicKey("key-1"); err == nil || !strings.Contains(err.Error(), "503") {
+				t.Errorf("Expected the failure to be reported, got %v", err)
+			}
+		}
+		if fetches != 1 {
+			t.Errorf("Expected the failure to be cached, got %d fetches", fetches)
+		}
+	})
+}
+
+func TestInformation_UserInfo(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"sub": "user-id", "email": "user@example.com", "email_verified": true})
+	}))
+	defer server.Close()
+
+	info := oauth.NewInformationQuerier()
+	info.UserInfoURL = server.URL
+
+	claims, err := info.UserInfo("access")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if claims.Subject != "user-id" || claims.Email != "user@example.com" || !claims.EmailVerified {
+		t.Errorf("Unexpected user info: %+v", claims)
+	}
+
+	if _, err = info.UserInfo("expired"); err == nil || !strings.HasPrefix(err.Error(), "[401]") {
+		t.Errorf("Expected the status to be reported, got %v", err)
+	}
+}
+
+func TestThreeLeggedAuth_AuthorizeWithNonce(t *testing.T) {
+
+	auth := oauth.NewThreeLeggedClientV2("client", "secret", "http://localhost/callback")
+
+	link, err := auth.AuthorizeWithNonce("data:read", "state", "the-nonce")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if scope := parsed.Query().Get("scope"); scope != "data:read openid" {
+		t.Errorf("Expected the openid scope to be requested, got %q", scope)
+	}
+	if nonce := parsed.Query().Get("nonce"); nonce != "the-nonce" {
+		t.Errorf("Expected the nonce to be sent, got %q", nonce)
+	}
+}
@@ -0,0 +1,37 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// PKCE holds the code verifier and its derived code challenge used to secure the authorization code flow
+// of public clients, such as desktop and command-line tools, that cannot keep a client secret (RFC 7636).
+//
+// A new PKCE should be generated for each authorization: the Challenge is sent with AuthorizeWithPKCE,
+// while the Verifier is kept by the client and sent with GetTokenWithVerifier.
+type PKCE struct {
+	Verifier        string
+	Challenge       string
+	ChallengeMethod string // Always S256
+}
+
+// NewPKCE returns a random code verifier along with its S256 code challenge
+func NewPKCE() (pkce PKCE, err error) {
+	buffer := make([]byte, 32)
+	if _, err = rand.Read(buffer); err != nil {
+		return
+	}
+
+	pkce.Verifier = base64.RawURLEncoding.EncodeToString(buffer)
+	pkce.Challenge = challengeS256(pkce.Verifier)
+	pkce.ChallengeMethod = "S256"
+
+	return
+}
+
+func challengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
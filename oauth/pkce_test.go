@@ -0,0 +1,82 @@
+package oauth_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestNewPKCE(t *testing.T) {
+	pkce, err := oauth.NewPKCE()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if len(pkce.Verifier) < 43 || len(pkce.Verifier) > 128 {
+		t.Errorf("The verifier length should be between 43 and 128, got %d", len(pkce.Verifier))
+	}
+
+	sum := sha256.Sum256([]byte(pkce.Verifier))
+	if expected := base64.RawURLEncoding.EncodeToString(sum[:]); pkce.Challenge != expected {
+		t.Errorf("Expected challenge %s, got %s", expected, pkce.Challenge)
+	}
+
+	other, _ := oauth.NewPKCE()
+	if other.Verifier == pkce.Verifier {
+		t.Error("Expected a different verifier on each call")
+	}
+}
+
+func TestThreeLeggedAuth_PKCEPublicClient(t *testing.T) {
+
+	pkce, err := oauth.NewPKCE()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		request = r
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "token", ExpiresIn: 3599})
+	}))
+	defer server.Close()
+
+	client := oauth.NewPublicThreeLeggedClient("client", "http://localhost:3009/callback")
+	client.Host = server.URL
+
+	link, err := client.AuthorizeWithPKCE("data:read", "state", pkce)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge") != pkce.Challenge || query.Get("code_challenge_method") != "S256" {
+		t.Errorf("The authorization link is missing the code challenge: %s", link)
+	}
+
+	if _, err := client.GetTokenWithVerifier("code", pkce.Verifier); err != nil {
+		t.Fatal(err.Error())
+	}
+	if request.PostForm.Get("code_verifier") != pkce.Verifier {
+		t.Errorf("Expected the verifier to be sent, got %v", request.PostForm)
+	}
+	if request.PostForm.Get("client_id") != "client" {
+		t.Error("A public client should send its client_id in the body")
+	}
+	if _, ok := request.PostForm["client_secret"]; ok {
+		t.Error("A public client should not send a client secret")
+	}
+	if _, _, ok := request.BasicAuth(); ok {
+		t.Error("A public client should not use HTTP Basic authentication")
+	}
+}
@@ -1,55 +1,330 @@
 package oauth
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 )
 
+// DefaultRefreshMargin is how long before its expiry a RefreshableToken is refreshed
+const DefaultRefreshMargin = 2 * time.Minute
+
+// defaultRefreshScope is requested when refreshing a token whose granted scope is unknown
+const defaultRefreshScope = "data:read"
+
+// backgroundRetryInterval is the delay before the background refresh retries after a transient failure
+const backgroundRetryInterval = 10 * time.Second
+
+// backgroundMinInterval is the minimum delay between two background refreshes, so that tokens living
+// less than RefreshMargin do not make the refresh loop hit the server continuously
+const backgroundMinInterval = 30 * time.Second
+
 type RefreshableToken struct {
 	bearer          *Bearer
 	TokenExpireTime time.Time
 	readMutex       sync.Mutex
 	writeMutex      sync.Mutex
+	store           TokenStore
+	storeKey        string
+	grantedScope    string // The scopes the user consented to, requested again on every refresh
+
+	// RefreshMargin makes the token refresh this long before TokenExpireTime,
+	// so that a request started right before the expiry does not go out with a dead token.
+	// Once the token is in use, e.g. after StartBackgroundRefresh, change it with SetRefreshMargin.
+	RefreshMargin time.Duration
+	// OnInvalidGrant, if set, is called once when the refresh token is rejected as invalid or expired.
+	// Such a failure is permanent: the user has to log in again and later refreshes return the same error.
+	OnInvalidGrant func(err error)
+	permanentErr   error
 }
 
 func NewRefreshableToken(bearer *Bearer, expiryTime time.Time) *RefreshableToken {
 	return &RefreshableToken{
 		bearer:          bearer,
 		TokenExpireTime: expiryTime,
+		RefreshMargin:   DefaultRefreshMargin,
+	}
+}
+
+// NewRefreshableTokenWithScope returns a token that remembers the scopes the user granted,
+// usually those passed to ThreeLeggedAuth.Authorize, and requests them again on every refresh.
+// Tokens created with NewRefreshableToken do not know their scope and are refreshed with "data:read".
+func NewRefreshableTokenWithScope(bearer *Bearer, expiryTime time.Time, scopes Scopes) *RefreshableToken {
+	token := NewRefreshableToken(bearer, expiryTime)
+	token.grantedScope = scopes.String()
+
+	return token
+}
+
+// NewRefreshableTokenWithStore returns a token with the given granted scopes that saves itself into store under key,
+// initially and after every refresh, so that the rotated refresh token survives restarts.
+func NewRefreshableTokenWithStore(bearer *Bearer, expiryTime time.Time, scopes Scopes, store TokenStore, key string) (*RefreshableToken, error) {
+	token := NewRefreshableTokenWithScope(bearer, expiryTime, scopes)
+	token.store = store
+	token.storeKey = key
+
+	if err := token.save(); err != nil {
+		return nil, err
 	}
+
+	return token, nil
+}
+
+// LoadRefreshableToken returns the token saved in store under key, which will keep being saved after every refresh.
+// It returns ErrTokenNotFound if there is no such token, e.g. when the user has not logged in yet.
+func LoadRefreshableToken(store TokenStore, key string) (*RefreshableToken, error) {
+	stored, err := store.Load(key)
+	if err != nil {
+		return nil, err
+	}
+
+	token := NewRefreshableTokenWithScope(&stored.Bearer, stored.ExpiresAt, scopesOf(stored.Scope))
+	token.store = store
+	token.storeKey = key
+
+	return token, nil
 }
 
+// RefreshTokenIfRequired refreshes the token, with the granted scope, if it expires within RefreshMargin
 func (t *RefreshableToken) RefreshTokenIfRequired(auth ThreeLeggedAuth) error {
+	_, err := t.refresh(auth, t.refreshMargin(), "")
+	return err
+}
+
+// RefreshTokenWithScope immediately gets an access token limited to the given scope and returns it, e.g. to pass it
+// to a less trusted component. The scope must be within the granted one, otherwise a ScopeError is returned.
+//
+// The token keeps its current access token and scope. Only its refresh token, which the refresh rotates,
+// is replaced.
+func (t *RefreshableToken) RefreshTokenWithScope(auth ThreeLeggedAuth, scope string) (*Bearer, error) {
+	if _, err := ParseScopes(scope); err != nil {
+		return nil, err
+	}
+	if granted := t.GrantedScope(); len(granted) != 0 {
+		if err := checkScope(scope, granted); err != nil {
+			return nil, err
+		}
+	}
+
+	return t.refresh(auth, forceRefresh, scope)
+}
+
+// RefreshTokenWithScopes works as RefreshTokenWithScope, for a set of scopes
+func (t *RefreshableToken) RefreshTokenWithScopes(auth ThreeLeggedAuth, scopes Scopes) (*Bearer, error) {
+	return t.RefreshTokenWithScope(auth, scopes.String())
+}
+
+// RequireScope returns a ScopeError if the current access token was not granted all the given space-separated scopes.
+// Tokens that do not know their scope accept any. The 3-legged APIs of the dm, md and recap packages do not call it,
+// leaving the server to decide which of the scopes an endpoint accepts are required.
+func (t *RefreshableToken) RequireScope(scope string) error {
 	t.writeMutex.Lock()
 	defer t.writeMutex.Unlock()
 
-	// Check if token has expired
-	now := time.Now()
-	expiryTime := t.TokenExpireTime
-	if now.Before(expiryTime) {
+	if len(t.grantedScope) == 0 {
 		return nil
 	}
 
-	refreshedBearer, err := auth.RefreshToken(t.bearer.RefreshToken, "data:read")
-	if err != nil {
-		return err
+	return checkScope(scope, t.grantedScope)
+}
+
+// RequireScopes works as RequireScope, for a set of scopes
+func (t *RefreshableToken) RequireScopes(scopes Scopes) error {
+	return t.RequireScope(scopes.String())
+}
+
+// GrantedScope returns the space-separated scopes the user granted, or an empty string if they are unknown
+func (t *RefreshableToken) GrantedScope() string {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	return t.grantedScope
+}
+
+// GrantedScopes returns the set of the scopes the user granted, empty if they are unknown
+func (t *RefreshableToken) GrantedScopes() Scopes {
+	return scopesOf(t.GrantedScope())
+}
+
+// Scope returns the space-separated scopes of the current access token, or an empty string if they are unknown.
+// They are the granted ones, as the narrower tokens returned by RefreshTokenWithScope are not kept.
+func (t *RefreshableToken) Scope() string {
+	return t.GrantedScope()
+}
+
+// StartBackgroundRefresh starts a goroutine that refreshes the token ahead of its expiry, so that API calls
+// never have to wait for a refresh. The refresh happens at a random point up to RefreshMargin before the
+// time RefreshTokenIfRequired would do it, so that many tokens obtained together do not all refresh at once.
+//
+// The goroutine stops when ctx is cancelled or when the refresh token is rejected as invalid.
+// Transient failures are retried, while RefreshTokenIfRequired still acts as a fallback.
+// Two background refreshes are at least 30 seconds apart, even for tokens living less than RefreshMargin.
+func (t *RefreshableToken) StartBackgroundRefresh(ctx context.Context, auth ThreeLeggedAuth) {
+	go t.backgroundRefresh(ctx, auth)
+}
+
+// backgroundRefresh runs the loop of StartBackgroundRefresh, returning once it stopped
+func (t *RefreshableToken) backgroundRefresh(ctx context.Context, auth ThreeLeggedAuth) {
+	// a token that has already expired is refreshed right away
+	var minDelay time.Duration
+	for {
+		margin := t.refreshMargin()
+		jitter := time.Duration(0)
+		if margin > 0 {
+			jitter = time.Duration(rand.Int63n(int64(margin)))
+		}
+		threshold := margin + jitter
+
+		delay := time.Until(t.ExpiryTime().Add(-threshold))
+		if delay < minDelay {
+			delay = minDelay
+		}
+		minDelay = backgroundMinInterval
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		_, err := t.refresh(auth, threshold, "")
+		if err == nil {
+			continue
+		}
+		if t.PermanentError() != nil {
+			return
+		}
+
+		retry := time.NewTimer(backgroundRetryInterval)
+		select {
+		case <-ctx.Done():
+			retry.Stop()
+			return
+		case <-retry.C:
+		}
 	}
+}
+
+// ExpiryTime returns the expiration time of the current access token
+func (t *RefreshableToken) ExpiryTime() time.Time {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	return t.TokenExpireTime
+}
 
-	// Refresh "now" and add new token expiration time to API struct along with new credentials
-	now = time.Now()
-	newExpiryTime := now.Add(time.Second * time.Duration(refreshedBearer.ExpiresIn))
-	t.TokenExpireTime = newExpiryTime
+// SetRefreshMargin changes the RefreshMargin of a token that may be in use by other goroutines
+func (t *RefreshableToken) SetRefreshMargin(margin time.Duration) {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	t.RefreshMargin = margin
+}
 
-	t.bearer.AccessToken = refreshedBearer.AccessToken
-	t.bearer.ExpiresIn = refreshedBearer.ExpiresIn
-	t.bearer.RefreshToken = refreshedBearer.RefreshToken
-	t.bearer.TokenType = refreshedBearer.TokenType
+// refreshMargin returns the RefreshMargin of the token
+func (t *RefreshableToken) refreshMargin() time.Duration {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	return t.RefreshMargin
+}
 
-	return nil
+// PermanentError returns the error that made refreshing impossible, or nil if the token can still be refreshed
+func (t *RefreshableToken) PermanentError() error {
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+	return t.permanentErr
 }
 
+// Bearer returns the current bearer. A refresh replaces the bearer instead of modifying it,
+// so the returned value is safe to use while the token is being refreshed.
 func (t *RefreshableToken) Bearer() *Bearer {
 	t.readMutex.Lock()
 	defer t.readMutex.Unlock()
 	return t.bearer
 }
+
+// forceRefresh is a threshold making refresh get a new token whatever the expiry of the current one
+const forceRefresh = time.Duration(1<<63 - 1)
+
+// refresh gets a new token with the granted scope if the current token expires within threshold, and returns it.
+// With a narrower scope, the new access token is only returned, the token keeping its current one.
+func (t *RefreshableToken) refresh(auth ThreeLeggedAuth, threshold time.Duration, scope string) (_ *Bearer, err error) {
+	// The hook is called once the lock is released, so that it can use the token
+	var invalidGrant error
+	defer func() {
+		if invalidGrant != nil && t.OnInvalidGrant != nil {
+			t.OnInvalidGrant(invalidGrant)
+		}
+	}()
+
+	t.writeMutex.Lock()
+	defer t.writeMutex.Unlock()
+
+	if t.permanentErr != nil {
+		return nil, t.permanentErr
+	}
+
+	// Check if token is about to expire
+	now := time.Now()
+	if threshold != forceRefresh && now.Before(t.TokenExpireTime.Add(-threshold)) {
+		return t.bearer, nil
+	}
+
+	narrowed := len(scope) != 0
+	if !narrowed {
+		scope = t.grantedScope
+	}
+	if len(scope) == 0 {
+		scope = defaultRefreshScope
+	}
+
+	refreshedBearer, err := auth.RefreshToken(t.bearer.RefreshToken, scope)
+	if err != nil {
+		if IsInvalidGrant(err) {
+			t.permanentErr = err
+			invalidGrant = err
+		}
+		return nil, err
+	}
+
+	bearer := &refreshedBearer
+	if narrowed {
+		// Keep the current access token, with the rotated refresh token
+		kept := *t.bearer
+		kept.RefreshToken = refreshedBearer.RefreshToken
+		bearer = &kept
+	} else {
+		// Refresh "now" and add new token expiration time to API struct along with new credentials
+		now = time.Now()
+		t.TokenExpireTime = now.Add(time.Second * time.Duration(refreshedBearer.ExpiresIn))
+	}
+
+	t.readMutex.Lock()
+	t.bearer = bearer
+	t.readMutex.Unlock()
+
+	// The previous refresh token is no longer valid, so the new one must be persisted before it is used
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+
+	return &refreshedBearer, nil
+}
+
+func (t *RefreshableToken) save() error {
+	if t.store == nil {
+		return nil
+	}
+
+	err := t.store.Save(t.storeKey, StoredToken{
+		Bearer:    *t.bearer,
+		ExpiresAt: t.TokenExpireTime,
+		Scope:     t.grantedScope,
+	})
+	if err != nil {
+		return fmt.Errorf("could not save the token: %w", err)
+	}
+
+	return nil
+}
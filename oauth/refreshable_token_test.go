@@ -0,0 +1,223 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func refreshServer(calls *int32, invalid bool) (oauth.ThreeLeggedAuth, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if invalid {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"The refresh token is invalid or expired."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "refreshed", RefreshToken: "rotated", ExpiresIn: 3599})
+	}))
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	auth.Host = server.URL
+
+	return auth, server.Close
+}
+
+func TestRefreshableToken_RefreshMargin(t *testing.T) {
+	var calls int32
+	auth, stop := refreshServer(&calls, false)
+	defer stop()
+
+	token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "old"}, time.Now().Add(time.Minute))
+
+	if err := token.RefreshTokenIfRequired(auth); err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.Bearer().AccessToken != "refreshed" {
+		t.Error("Expected a token expiring within the refresh margin to be refreshed")
+	}
+
+	if err := token.RefreshTokenIfRequired(auth); err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected a single refresh, got %d", n)
+	}
+}
+
+func TestRefreshableToken_StartBackgroundRefresh(t *testing.T) {
+	var calls int32
+	auth, stop := refreshServer(&calls, false)
+	defer stop()
+
+	token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "old"}, time.Now().Add(300*time.Millisecond))
+	token.RefreshMargin = 100 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	token.StartBackgroundRefresh(ctx, auth)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for token.Bearer().AccessToken != "refreshed" {
+		if time.Now().After(deadline) {
+			t.Fatal("The token was not refreshed in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if !token.ExpiryTime().After(time.Now().Add(time.Hour - time.Minute)) {
+		t.Errorf("Unexpected expiry time after refresh: %v", token.ExpiryTime())
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected a single refresh, got %d", n)
+	}
+
+	t.Run("Short-lived token", func(t *testing.T) {
+		var calls int32
+		auth, stop := refreshServer(&calls, false)
+		defer stop()
+
+		// the refreshed tokens expire within the margin, so they always need a refresh
+		token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "old"}, time.Now())
+		token.RefreshMargin = 2 * time.Hour
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		token.StartBackgroundRefresh(ctx, auth)
+		time.Sleep(300 * time.Millisecond)
+
+		if token.Bearer().AccessToken != "refreshed" {
+			t.Error("Expected the expired token to be refreshed right away")
+		}
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Errorf("Expected the refreshes to be spaced out, got %d calls", n)
+		}
+	})
+
+	t.Run("Margin changed while running", func(t *testing.T) {
+		var calls int32
+		auth, stop := refreshServer(&calls, false)
+		defer stop()
+
+		token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "old"}, time.Now())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		token.StartBackgroundRefresh(ctx, auth)
+		// run with -race: the background refresh reads the margin while it is changed
+		token.SetRefreshMargin(time.Minute)
+
+		deadline := time.Now().Add(2 * time.Second)
+		for token.Bearer().AccessToken != "refreshed" {
+			if time.Now().After(deadline) {
+				t.Fatal("The token was not refreshed in the background")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+}
+
+func TestRefreshableToken_OnInvalidGrant(t *testing.T) {
+	var calls int32
+	auth, stop := refreshServer(&calls, true)
+	defer stop()
+
+	token := oauth.NewRefreshableToken(&oauth.Bearer{AccessToken: "old"}, time.Now())
+
+	notified := make(chan error, 2)
+	token.OnInvalidGrant = func(err error) {
+		notified <- token.PermanentError()
+	}
+
+	if err := token.RefreshTokenIfRequired(auth); err == nil {
+		t.Fatal("Expected the refresh to fail")
+	}
+	if err := token.RefreshTokenIfRequired(auth); err == nil {
+		t.Fatal("Expected the refresh to keep failing")
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected the permanent failure not to be retried, got %d calls", n)
+	}
+	if len(notified) != 1 {
+		t.Fatalf("Expected a single notification, got %d", len(notified))
+	}
+	if err := <-notified; err == nil {
+		t.Error("Expected the permanent error to be set when notified")
+	}
+}
+
+func TestRefreshableToken_PreservesScope(t *testing.T) {
+
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.FormValue("scope"))
+		// the access tokens are told apart by their scope
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: r.FormValue("scope"), RefreshToken: "rotated", ExpiresIn: 3599})
+	}))
+	defer server.Close()
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	auth.Host = server.URL
+
+	token := oauth.NewRefreshableTokenWithScope(&oauth.Bearer{}, time.Now(), oauth.NewScopes(oauth.ScopeDataRead, oauth.ScopeDataWrite, oauth.ScopeBucketCreate))
+
+	if err := token.RefreshTokenIfRequired(auth); err != nil {
+		t.Fatal(err.Error())
+	}
+	if requested[0] != "bucket:create data:read data:write" {
+		t.Errorf("Expected the granted scope to be requested, got %s", requested[0])
+	}
+
+	stored := token.Bearer()
+	narrowed, err := token.RefreshTokenWithScope(auth, "data:read")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if requested[1] != "data:read" || narrowed.AccessToken != "data:read" {
+		t.Errorf("Expected a narrower token to be returned, requested %s and got %+v", requested[1], narrowed)
+	}
+	if bearer := token.Bearer(); bearer.AccessToken != stored.AccessToken || bearer.RefreshToken != "rotated" {
+		t.Errorf("Expected the token to keep its access token with the rotated refresh token, got %+v", bearer)
+	}
+	if err := token.RequireScope("data:write"); err != nil || token.Scope() != "bucket:create data:read data:write" {
+		t.Errorf("Expected the token to keep its granted scope, got %s and %v", token.Scope(), err)
+	}
+
+	err = token.RequireScope("data:create")
+	scopeErr, ok := err.(*oauth.ScopeError)
+	if !ok {
+		t.Fatalf("Expected a ScopeError, got %v", err)
+	}
+	if len(scopeErr.Missing) != 1 || scopeErr.Missing[0] != "data:create" {
+		t.Errorf("Unexpected missing scopes: %v", scopeErr.Missing)
+	}
+
+	if _, err := token.RefreshTokenWithScope(auth, "data:read data:create"); err == nil {
+		t.Error("Expected to fail refreshing with a scope that was not granted")
+	}
+	if len(requested) != 2 {
+		t.Errorf("A scope that was not granted should not be requested, got %v", requested)
+	}
+
+	if err := token.RequireScope("data:read"); err != nil {
+		t.Errorf("Did not expect an error for a granted scope, got %s", err.Error())
+	}
+
+	legacy := oauth.NewRefreshableToken(&oauth.Bearer{}, time.Now())
+	if err := legacy.RefreshTokenIfRequired(auth); err != nil {
+		t.Fatal(err.Error())
+	}
+	if requested[2] != "data:read" {
+		t.Errorf("Expected a token without known scope to be refreshed with data:read, got %s", requested[2])
+	}
+	if err := legacy.RequireScope("data:write"); err != nil {
+		t.Errorf("A token without known scope should accept any, got %s", err.Error())
+	}
+}
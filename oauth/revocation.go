@@ -0,0 +1,85 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// TokenTypeHint tells the authentication server which kind of token is passed to Revoke
+type TokenTypeHint string
+
+const (
+	// AccessTokenHint marks the token as an access token
+	AccessTokenHint TokenTypeHint = "access_token"
+	// RefreshTokenHint marks the token as a refresh token
+	RefreshTokenHint TokenTypeHint = "refresh_token"
+)
+
+// TokenIntrospection reflects the response when inspecting a token
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`               // false if the token is expired, revoked or unknown
+	Scope     string `json:"scope,omitempty"`      // Space-separated list of the scopes granted to the token
+	ClientID  string `json:"client_id,omitempty"`  // The client the token was issued to
+	UserID    string `json:"userid,omitempty"`     // The user that authorized the token, empty for 2-legged tokens
+	TokenType string `json:"token_type,omitempty"` // The type of the token, e.g. Bearer
+	Expiry    int64  `json:"exp,omitempty"`        // Expiration time, in seconds since the Unix epoch
+}
+
+// ExpiresAt returns the expiration time of the token, or the zero time if it was not reported
+func (i TokenIntrospection) ExpiresAt() time.Time {
+	if i.Expiry == 0 {
+		return time.Time{}
+	}
+	return time.Unix(i.Expiry, 0)
+}
+
+// Revoke invalidates the given access or refresh token, e.g. when a user disconnects the integration.
+// Revoking a refresh token also invalidates the access tokens obtained with it.
+//	Note: revocation is only available in the v2 Authentication API and is used whatever the client Version:
+//	v1 clients send the request to AuthPath with its /authentication/v1 suffix replaced by /authentication/v2.
+func (a AuthData) Revoke(token string, hint TokenTypeHint) error {
+
+	body := url.Values{}
+	body.Add("token", token)
+	if len(hint) != 0 {
+		body.Add("token_type_hint", string(hint))
+	}
+
+	response, err := a.postForm(AuthV2, a.Host+a.v2Path()+"/revoke", body)
+	if err != nil {
+		return err
+	}
+
+	return response.Body.Close()
+}
+
+// Introspect returns whether the given token is active along with the scopes, client, user and expiry it was issued with.
+//	Note: introspection is only available in the v2 Authentication API and is used whatever the client Version:
+//	v1 clients send the request to AuthPath with its /authentication/v1 suffix replaced by /authentication/v2.
+func (a AuthData) Introspect(token string) (result TokenIntrospection, err error) {
+
+	body := url.Values{}
+	body.Add("token", token)
+
+	response, err := a.postForm(AuthV2, a.Host+a.v2Path()+"/introspect", body)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+	err = decoder.Decode(&result)
+
+	return
+}
+
+// Revoke invalidates the given token and drops the tokens kept in the client cache,
+// so that a revoked token is not handed out again.
+func (a TwoLeggedAuth) Revoke(token string, hint TokenTypeHint) error {
+	if credentials, err := a.credentials(); err == nil && a.Cache != nil {
+		a.Cache.Invalidate(credentials.ClientID)
+	}
+
+	return a.AuthData.Revoke(token, hint)
+}
@@ -0,0 +1,105 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestAuthData_RevokeAndIntrospect(t *testing.T) {
+
+	revoked := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if id, secret, ok := r.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/authentication/v2/revoke":
+			if r.PostForm.Get("token_type_hint") != "refresh_token" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			revoked[r.PostForm.Get("token")] = true
+		case "/authentication/v2/introspect":
+			json.NewEncoder(w).Encode(oauth.TokenIntrospection{
+				Active:   !revoked[r.PostForm.Get("token")],
+				Scope:    "data:read data:write",
+				ClientID: "client",
+				UserID:   "USER",
+				Expiry:   1600000000,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// the v1 client still uses the v2 endpoints, which are the only ones available
+	client := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	client.Host = server.URL
+
+	info, err := client.Introspect("refresh")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !info.Active || info.Scope != "data:read data:write" || info.UserID != "USER" || info.ExpiresAt().Unix() != 1600000000 {
+		t.Errorf("Unexpected introspection result: %+v", info)
+	}
+
+	if err := client.Revoke("refresh", oauth.RefreshTokenHint); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	info, err = client.Introspect("refresh")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if info.Active {
+		t.Error("Expected the revoked token to be inactive")
+	}
+
+	client.ClientSecret = "wrong"
+	if err := client.Revoke("refresh", oauth.RefreshTokenHint); err == nil {
+		t.Error("Expected to fail revoking with wrong credentials")
+	}
+
+	t.Run("AuthPath", func(t *testing.T) {
+		var paths []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			paths = append(paths, r.URL.Path)
+			w.Write([]byte(`{"active":true}`))
+		}))
+		defer server.Close()
+
+		v1 := oauth.NewTwoLeggedClient("client", "secret")
+		v1.Host = server.URL
+		v1.AuthPath = "/gateway/authentication/v1"
+		v2 := oauth.NewTwoLeggedClientV2("client", "secret")
+		v2.Host = server.URL
+		v2.AuthPath = "/gateway/auth"
+
+		for _, client := range []oauth.TwoLeggedAuth{v1, v2} {
+			if err := client.Revoke("token", oauth.AccessTokenHint); err != nil {
+				t.Fatal(err.Error())
+			}
+			if _, err := client.Introspect("token"); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+
+		expected := []string{
+			"/gateway/authentication/v2/revoke", "/gateway/authentication/v2/introspect",
+			"/gateway/auth/revoke", "/gateway/auth/introspect",
+		}
+		if strings.Join(paths, " ") != strings.Join(expected, " ") {
+			t.Errorf("Expected the requests to be sent to %q, got %q", expected, paths)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidScope is returned when a scope is not one of the scopes known by the Forge Authentication API.
+// Scopes added to the API after this release can be accepted with RegisterScope.
+var ErrInvalidScope = errors.New("invalid scope")
+
+// Scope is a single permission requested for a token
+type Scope string
+
+// The scopes supported by the Forge Authentication API
+const (
+	ScopeUserProfileRead Scope = "user-profile:read" // Read the profile of the end user
+	ScopeUserRead        Scope = "user:read"         // Read the profile of the end user, including associated products and services
+	ScopeUserWrite       Scope = "user:write"        // Modify the profile of the end user
+	ScopeViewablesRead   Scope = "viewables:read"    // View the derivatives of models, without downloading the source data
+	ScopeDataRead        Scope = "data:read"         // Read data, including buckets, objects and derivatives
+	ScopeDataWrite       Scope = "data:write"        // Modify existing data
+	ScopeDataCreate      Scope = "data:create"       // Create new data
+	ScopeDataSearch      Scope = "data:search"       // Search across data
+	ScopeBucketCreate    Scope = "bucket:create"     // Create buckets
+	ScopeBucketRead      Scope = "bucket:read"       // Read the metadata and list the contents of buckets
+	ScopeBucketUpdate    Scope = "bucket:update"     // Update the metadata of buckets
+	ScopeBucketDelete    Scope = "bucket:delete"     // Delete buckets
+	ScopeCodeAll         Scope = "code:all"          // Author and execute code, e.g. Design Automation
+	ScopeAccountRead     Scope = "account:read"      // Read the data of a product account
+	ScopeAccountWrite    Scope = "account:write"     // Modify the data of a product account
+	ScopeOpenID          Scope = "openid"            // Get an ID token identifying the end user
+)
+
+var knownScopesMutex sync.RWMutex
+
+var knownScopes = map[Scope]bool{
+	ScopeUserProfileRead: true,
+	ScopeUserRead:        true,
+	ScopeUserWrite:       true,
+	ScopeViewablesRead:   true,
+	ScopeDataRead:        true,
+	ScopeDataWrite:       true,
+	ScopeDataCreate:      true,
+	ScopeDataSearch:      true,
+	ScopeBucketCreate:    true,
+	ScopeBucketRead:      true,
+	ScopeBucketUpdate:    true,
+	ScopeBucketDelete:    true,
+	ScopeCodeAll:         true,
+	ScopeAccountRead:     true,
+	ScopeAccountWrite:    true,
+	ScopeOpenID:          true,
+}
+
+// RegisterScope adds scopes to the ones known by the Forge Authentication API, so that they are accepted
+// by the validation of the clients, e.g. for a scope introduced by Autodesk after this release
+func RegisterScope(scopes ...Scope) {
+	knownScopesMutex.Lock()
+	defer knownScopesMutex.Unlock()
+
+	for _, scope := range scopes {
+		knownScopes[scope] = true
+	}
+}
+
+// Valid reports whether the scope is known by the Forge Authentication API, or was added with RegisterScope
+func (s Scope) Valid() bool {
+	knownScopesMutex.RLock()
+	defer knownScopesMutex.RUnlock()
+
+	return knownScopes[s]
+}
+
+// Scopes is a set of scopes, kept sorted and without duplicates
+type Scopes []Scope
+
+// NewScopes returns the set of the given scopes
+func NewScopes(scopes ...Scope) Scopes {
+	set := append(Scopes(nil), scopes...)
+	sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+
+	unique := set[:0]
+	for i, scope := range set {
+		if i == 0 || scope != set[i-1] {
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
+
+// ParseScopes returns the set of the space-separated scopes, or an error wrapping ErrInvalidScope
+// if any of them is unknown
+func ParseScopes(scope string) (Scopes, error) {
+	set := scopesOf(scope)
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// scopesOf returns the set of the space-separated scopes, whether they are known or not
+func scopesOf(scope string) Scopes {
+	fields := strings.Fields(scope)
+	set := make(Scopes, 0, len(fields))
+	for _, field := range fields {
+		set = append(set, Scope(field))
+	}
+
+	return NewScopes(set...)
+}
+
+// Validate returns an error wrapping ErrInvalidScope for the first unknown scope of the set
+func (s Scopes) Validate() error {
+	for _, scope := range s {
+		if !scope.Valid() {
+			return fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+
+	return nil
+}
+
+// String returns the space-separated scopes, as expected by the Forge Authentication API
+func (s Scopes) String() string {
+	fields := make([]string, len(s))
+	for i, scope := range s {
+		fields[i] = string(scope)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// Contains reports whether the scope is in the set
+func (s Scopes) Contains(scope Scope) bool {
+	i := sort.Search(len(s), func(i int) bool { return s[i] >= scope })
+	return i < len(s) && s[i] == scope
+}
+
+// Union returns the set of the scopes in either set
+func (s Scopes) Union(other Scopes) Scopes {
+	return NewScopes(append(append(Scopes(nil), s...), other...)...)
+}
+
+// IsSubsetOf reports whether all the scopes of the set are in other
+func (s Scopes) IsSubsetOf(other Scopes) bool {
+	return len(s.missingFrom(other)) == 0
+}
+
+// missingFrom returns the scopes of the set that are not in other
+func (s Scopes) missingFrom(other Scopes) []string {
+	var missing []string
+	for _, scope := range s {
+		if !other.Contains(scope) {
+			missing = append(missing, string(scope))
+		}
+	}
+
+	return missing
+}
+
+// ScopeError is returned when a token is used or refreshed for a scope it was not granted
+type ScopeError struct {
+	Required string   // The space-separated scopes needed
+	Granted  string   // The space-separated scopes of the token
+	Missing  []string // The required scopes the token lacks
+}
+
+func (e *ScopeError) Error() string {
+	return "token lacks scope " + strings.Join(e.Missing, " ") + " (granted: " + e.Granted + ")"
+}
+
+// checkScope returns a ScopeError if the space-separated required scopes are not all within granted
+func checkScope(required, granted string) error {
+	missing := scopesOf(required).missingFrom(scopesOf(granted))
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return &ScopeError{
+		Required: required,
+		Granted:  granted,
+		Missing:  missing,
+	}
+}
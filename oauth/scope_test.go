@@ -0,0 +1,98 @@
+package oauth_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestParseScopes(t *testing.T) {
+
+	t.Run("Valid scopes", func(t *testing.T) {
+		scopes, err := oauth.ParseScopes(" data:write data:read  data:write ")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if scopes.String() != "data:read data:write" {
+			t.Errorf("Expected the scopes to be sorted and unique, got %q", scopes.String())
+		}
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		_, err := oauth.ParseScopes("data:read data:improvise")
+		if !errors.Is(err, oauth.ErrInvalidScope) {
+			t.Errorf("Expected ErrInvalidScope, got %v", err)
+		}
+	})
+
+	t.Run("Registered scope", func(t *testing.T) {
+		// the registered scopes are global, so each run registers a new one
+		future := oauth.Scope(fmt.Sprintf("data:future-%d", time.Now().UnixNano()))
+		if _, err := oauth.ParseScopes("data:read " + string(future)); !errors.Is(err, oauth.ErrInvalidScope) {
+			t.Fatalf("Expected ErrInvalidScope before the scope is registered, got %v", err)
+		}
+		oauth.RegisterScope(future)
+		if _, err := oauth.ParseScopes("data:read " + string(future)); err != nil {
+			t.Errorf("Expected the registered scope to be accepted, got %v", err)
+		}
+	})
+}
+
+func TestScopes_SetOperations(t *testing.T) {
+
+	read := oauth.NewScopes(oauth.ScopeDataRead, oauth.ScopeBucketRead)
+	write := oauth.NewScopes(oauth.ScopeDataWrite, oauth.ScopeDataRead)
+	union := read.Union(write)
+
+	if union.String() != "bucket:read data:read data:write" {
+		t.Errorf("Unexpected union: %q", union.String())
+	}
+	if !union.Contains(oauth.ScopeDataWrite) || union.Contains(oauth.ScopeDataCreate) {
+		t.Errorf("Unexpected content of %q", union.String())
+	}
+	if !read.IsSubsetOf(union) || !write.IsSubsetOf(union) {
+		t.Error("Expected both sets to be subsets of their union")
+	}
+	if read.IsSubsetOf(write) {
+		t.Error("Expected bucket:read to be missing from the write scopes")
+	}
+	if !oauth.NewScopes().IsSubsetOf(read) {
+		t.Error("Expected the empty set to be a subset of any set")
+	}
+}
+
+func TestAuthenticate_InvalidScopeIsRejectedLocally(t *testing.T) {
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	authenticator := oauth.NewTwoLeggedClientV2("client", "secret")
+	authenticator.Host = server.URL
+
+	if _, err := authenticator.Authenticate("data:improvise"); !errors.Is(err, oauth.ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope, got %v", err)
+	}
+
+	threeLegged := oauth.NewThreeLeggedClientV2("client", "secret", "http://localhost/callback")
+	threeLegged.Host = server.URL
+
+	if _, err := threeLegged.Authorize("data:improvise", "state"); !errors.Is(err, oauth.ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope from Authorize, got %v", err)
+	}
+	if _, err := threeLegged.RefreshToken("refresh", "data:improvise"); !errors.Is(err, oauth.ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope from RefreshToken, got %v", err)
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no request to be sent, got %d", requests)
+	}
+}
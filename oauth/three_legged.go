@@ -1,14 +1,8 @@
 package oauth
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
-	"time"
 )
 
 // ThreeLeggedAuth struct holds data necessary for making requests in 3-legged context
@@ -27,26 +21,67 @@ type ThreeLeggedAuthenticator interface {
 // NewThreeLeggedClient returns a 3-legged authenticator with default host and authPath
 func NewThreeLeggedClient(clientID, clientSecret, redirectURI string) ThreeLeggedAuth {
 	return ThreeLeggedAuth{
-		AuthData{
-			clientID,
-			clientSecret,
-			"https://developer.api.autodesk.com",
-			"/authentication/v1",
-			time.Now(),
-		},
+		newAuthData(clientID, clientSecret, AuthV1),
 		redirectURI,
 	}
 }
 
+// NewPublicThreeLeggedClient returns a 3-legged authenticator using the v2 Authentication API for a public client,
+// that has no client secret and must use PKCE to obtain its tokens
+func NewPublicThreeLeggedClient(clientID, redirectURI string) ThreeLeggedAuth {
+	return NewThreeLeggedClientV2(clientID, "", redirectURI)
+}
+
+// NewThreeLeggedClientV2 returns a 3-legged authenticator using the v2 Authentication API
+func NewThreeLeggedClientV2(clientID, clientSecret, redirectURI string) ThreeLeggedAuth {
+	return ThreeLeggedAuth{
+		newAuthData(clientID, clientSecret, AuthV2),
+		redirectURI,
+	}
+}
+
+// NewThreeLeggedClientWithProvider returns a 3-legged authenticator using the v2 Authentication API,
+// reading its credentials from provider on every request
+func NewThreeLeggedClientWithProvider(provider CredentialsProvider, redirectURI string) ThreeLeggedAuth {
+	auth := NewThreeLeggedClientV2("", "", redirectURI)
+	auth.Credentials = provider
+
+	return auth
+}
+
 // Authorize method returns an URL to redirect an end user, where it will be asked to give his consent for app to
 //access the specified resources.
 //
 // The resources for which the permission is asked are specified as a space-separated list of required scopes.
+// Unknown scopes are rejected with an error wrapping ErrInvalidScope.
 // State can be used to specify, as URL-encoded payload, some arbitrary data that the authentication flow will pass back
 // verbatim in a state query parameter to the callback URL.
 //	Note: You do not call this URL directly in your server code.
 //	See the Get a 3-Legged Token tutorial for more information on how to use this endpoint.
 func (a ThreeLeggedAuth) Authorize(scope string, state string) (string, error) {
+	return a.authorizeURL(scope, state, nil)
+}
+
+// AuthorizeWithScopes works as Authorize, for a set of scopes
+func (a ThreeLeggedAuth) AuthorizeWithScopes(scopes Scopes, state string) (string, error) {
+	return a.authorizeURL(scopes.String(), state, nil)
+}
+
+// AuthorizeWithPKCE works as Authorize, additionally adding to the URL the code challenge of the given PKCE.
+// The authorization code received on the callback must then be exchanged using GetTokenWithVerifier
+// and the same PKCE verifier.
+func (a ThreeLeggedAuth) AuthorizeWithPKCE(scope string, state string, pkce PKCE) (string, error) {
+	return a.authorizeURL(scope, state, url.Values{
+		"code_challenge":        {pkce.Challenge},
+		"code_challenge_method": {pkce.ChallengeMethod},
+	})
+}
+
+func (a ThreeLeggedAuth) authorizeURL(scope string, state string, extra url.Values) (string, error) {
+
+	if _, err := ParseScopes(scope); err != nil {
+		return "", err
+	}
 
 	request, err := http.NewRequest("GET",
 		a.Host+a.AuthPath+"/authorize",
@@ -57,12 +92,22 @@ func (a ThreeLeggedAuth) Authorize(scope string, state string) (string, error) {
 		return "", err
 	}
 
+	credentials, err := a.credentials()
+	if err != nil {
+		return "", err
+	}
+
 	query := request.URL.Query()
-	query.Add("client_id", a.ClientID)
+	query.Add("client_id", credentials.ClientID)
 	query.Add("response_type", "code")
 	query.Add("redirect_uri", a.RedirectURI)
 	query.Add("scope", scope)
 	query.Add("state", state)
+	for key, values := range extra {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
 
 	request.URL.RawQuery = query.Encode()
 
@@ -72,80 +117,38 @@ func (a ThreeLeggedAuth) Authorize(scope string, state string) (string, error) {
 //GetToken is used to exchange the authorization code for a token and an exchange token
 func (a ThreeLeggedAuth) GetToken(code string) (bearer Bearer, err error) {
 
-	task := http.Client{}
-
 	body := url.Values{}
-	body.Add("client_id", a.ClientID)
-	body.Add("client_secret", a.ClientSecret)
 	body.Add("grant_type", "authorization_code")
 	body.Add("code", code)
 	body.Add("redirect_uri", a.RedirectURI)
 
-	req, err := http.NewRequest("POST",
-		a.Host+a.AuthPath+"/gettoken",
-		bytes.NewBufferString(body.Encode()),
-	)
-
-	if err != nil {
-		return
-	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := task.Do(req)
-
-	if err != nil {
-		return
-	}
-
-	defer response.Body.Close()
+	return a.requestToken("/gettoken", body)
+}
 
-	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
-		return
-	}
+// GetTokenWithVerifier exchanges an authorization code obtained through AuthorizeWithPKCE,
+// sending the PKCE code verifier that proves the exchange is made by the client that started the flow
+func (a ThreeLeggedAuth) GetTokenWithVerifier(code string, verifier string) (bearer Bearer, err error) {
 
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&bearer)
+	body := url.Values{}
+	body.Add("grant_type", "authorization_code")
+	body.Add("code", code)
+	body.Add("redirect_uri", a.RedirectURI)
+	body.Add("code_verifier", verifier)
 
-	return
+	return a.requestToken("/gettoken", body)
 }
 
 // RefreshToken is used to get a new access token by using the refresh token provided by GetToken
 func (a ThreeLeggedAuth) RefreshToken(refreshToken string, scope string) (bearer Bearer, err error) {
-	task := http.Client{}
+
+	if _, err = ParseScopes(scope); err != nil {
+		return
+	}
 
 	body := url.Values{}
-	body.Add("client_id", a.ClientID)
-	body.Add("client_secret", a.ClientSecret)
 	body.Add("grant_type", "refresh_token")
 	body.Add("refresh_token", refreshToken)
 	body.Add("scope", scope)
 
-	req, err := http.NewRequest("POST",
-		a.Host+a.AuthPath+"/refreshtoken",
-		bytes.NewBufferString(body.Encode()),
-	)
-
-	if err != nil {
-		return
-	}
-
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := task.Do(req)
-
-	if err != nil {
-		return
-	}
-
-	defer response.Body.Close()
-
-	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
-		return
-	}
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&bearer)
-
-	return
+	return a.requestToken("/refreshtoken", body)
 }
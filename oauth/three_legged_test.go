@@ -1,10 +1,13 @@
 package oauth_test
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
-	"github.com/apprentice3d/forge-api-go-client/oauth"
+	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
 func TestThreeLeggedAuth_Authorize(t *testing.T) {
@@ -35,3 +38,42 @@ func TestThreeLeggedAuth_Authorize(t *testing.T) {
 	}
 
 }
+
+func TestThreeLeggedAuth_GetTokenV2(t *testing.T) {
+
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		request = r
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "token", RefreshToken: "refresh", ExpiresIn: 3599})
+	}))
+	defer server.Close()
+
+	client := oauth.NewThreeLeggedClientV2("client", "secret", "http://localhost:3009/callback")
+	client.Host = server.URL
+
+	if _, err := client.GetToken("code"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if request.URL.Path != "/authentication/v2/token" || request.PostForm.Get("grant_type") != "authorization_code" {
+		t.Errorf("Unexpected code exchange: %s %v", request.URL.Path, request.PostForm)
+	}
+
+	if _, err := client.RefreshToken("refresh", "data:read"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if request.URL.Path != "/authentication/v2/token" || request.PostForm.Get("grant_type") != "refresh_token" {
+		t.Errorf("Unexpected refresh: %s %v", request.URL.Path, request.PostForm)
+	}
+	if _, _, ok := request.BasicAuth(); !ok {
+		t.Error("Expected HTTP Basic client authentication")
+	}
+
+	link, err := client.Authorize("data:read", "state")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if want := server.URL + "/authentication/v2/authorize?"; len(link) < len(want) || link[:len(want)] != want {
+		t.Errorf("Unexpected authorization link: %s", link)
+	}
+}
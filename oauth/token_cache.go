@@ -0,0 +1,114 @@
+package oauth
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenExpiryMargin is how long before the reported expiry a cached token stops being handed out
+const DefaultTokenExpiryMargin = 60 * time.Second
+
+// TokenCache keeps 2-legged bearers by scope and reuses them until shortly before they expire.
+// Concurrent requests for the same scope that find no usable token share a single call to the
+// authentication server.
+//
+// A TokenCache is safe for concurrent use. The same cache can be assigned to several API clients
+// built with the same credentials, so that they share their tokens.
+type TokenCache struct {
+	// ExpiryMargin is subtracted from the token lifetime (Bearer.ExpiresIn) when deciding if a cached token
+	// can still be used
+	ExpiryMargin time.Duration
+
+	mutex   sync.Mutex
+	tokens  map[string]cachedBearer
+	pending map[string]*pendingFetch
+}
+
+type cachedBearer struct {
+	bearer Bearer
+	// expiry is when the bearer expires, reuseUntil when it stops being handed out
+	expiry     time.Time
+	reuseUntil time.Time
+}
+
+type pendingFetch struct {
+	done   chan struct{}
+	bearer Bearer
+	expiry time.Time
+	err    error
+}
+
+// NewTokenCache returns an empty TokenCache using DefaultTokenExpiryMargin
+func NewTokenCache() *TokenCache {
+	return &TokenCache{
+		ExpiryMargin: DefaultTokenExpiryMargin,
+	}
+}
+
+// Token returns the cached bearer stored under the given key and scope, or calls fetch to get a new one
+// when there is none or it is about to expire. Only successfully fetched bearers are cached.
+func (c *TokenCache) Token(key, scope string, fetch func(scope string) (Bearer, error)) (Bearer, error) {
+	bearer, _, err := c.token(key, scope, fetch)
+	return bearer, err
+}
+
+// token works as Token, also returning when the bearer expires, which for a cached bearer is earlier than
+// its ExpiresIn from now
+func (c *TokenCache) token(key, scope string, fetch func(scope string) (Bearer, error)) (Bearer, time.Time, error) {
+	// "data:write data:read" and "data:read data:write" share a cache entry
+	scope = scopesOf(scope).String()
+	id := key + "\x00" + scope
+
+	c.mutex.Lock()
+	if cached, ok := c.tokens[id]; ok && time.Now().Before(cached.reuseUntil) {
+		c.mutex.Unlock()
+		return cached.bearer, cached.expiry, nil
+	}
+
+	if call, ok := c.pending[id]; ok {
+		c.mutex.Unlock()
+		<-call.done
+		return call.bearer, call.expiry, call.err
+	}
+
+	call := &pendingFetch{done: make(chan struct{})}
+	if c.pending == nil {
+		c.pending = make(map[string]*pendingFetch)
+	}
+	c.pending[id] = call
+	c.mutex.Unlock()
+
+	requestedAt := time.Now()
+	call.bearer, call.err = fetch(scope)
+	call.expiry = requestedAt.Add(time.Duration(call.bearer.ExpiresIn) * time.Second)
+
+	c.mutex.Lock()
+	delete(c.pending, id)
+	if call.err == nil {
+		if c.tokens == nil {
+			c.tokens = make(map[string]cachedBearer)
+		}
+		c.tokens[id] = cachedBearer{
+			bearer:     call.bearer,
+			expiry:     call.expiry,
+			reuseUntil: call.expiry.Add(-c.ExpiryMargin),
+		}
+	}
+	c.mutex.Unlock()
+	close(call.done)
+
+	return call.bearer, call.expiry, call.err
+}
+
+// Invalidate drops every cached token stored under the given key, forcing the next call to fetch a new one
+func (c *TokenCache) Invalidate(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for id := range c.tokens {
+		if strings.HasPrefix(id, key+"\x00") {
+			delete(c.tokens, id)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestTwoLeggedAuth_AuthenticateCached(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		json.NewEncoder(w).Encode(oauth.Bearer{
+			TokenType:   "Bearer",
+			ExpiresIn:   3599,
+			AccessToken: "token-" + r.FormValue("scope") + "-" + strconv.Itoa(int(n)),
+		})
+	}))
+	defer server.Close()
+
+	authenticator := oauth.NewTwoLeggedClient("client", "secret")
+	authenticator.Host = server.URL
+
+	t.Run("Concurrent calls share one request", func(t *testing.T) {
+		wg := sync.WaitGroup{}
+		tokens := make([]string, 10)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				bearer, err := authenticator.Authenticate("data:read")
+				if err != nil {
+					t.Error(err.Error())
+				}
+				tokens[i] = bearer.AccessToken
+			}(i)
+		}
+		wg.Wait()
+
+		if n := atomic.LoadInt32(&calls); n != 1 {
+			t.Fatalf("Expected a single authentication request, got %d", n)
+		}
+		for _, token := range tokens {
+			if token != tokens[0] {
+				t.Errorf("Expected all callers to receive %s, got %s", tokens[0], token)
+			}
+		}
+	})
+
+	t.Run("Scope order does not matter", func(t *testing.T) {
+		first, _ := authenticator.Authenticate("data:read data:write")
+		second, _ := authenticator.Authenticate("data:write data:read")
+
+		if first.AccessToken != second.AccessToken {
+			t.Errorf("Expected the same token, got %s and %s", first.AccessToken, second.AccessToken)
+		}
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Errorf("Expected 2 authentication requests, got %d", n)
+		}
+	})
+
+	t.Run("Copies share the cache", func(t *testing.T) {
+		copied := authenticator
+		if _, err := copied.Authenticate("data:read"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if n := atomic.LoadInt32(&calls); n != 2 {
+			t.Errorf("Expected the cached token to be reused, got %d requests", n)
+		}
+	})
+
+	t.Run("Invalidate forces a new request", func(t *testing.T) {
+		authenticator.Cache.Invalidate("client")
+		if _, err := authenticator.Authenticate("data:read"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if n := atomic.LoadInt32(&calls); n != 3 {
+			t.Errorf("Expected 3 authentication requests, got %d", n)
+		}
+	})
+}
+
+func TestTokenCache_Expiry(t *testing.T) {
+	cache := oauth.NewTokenCache()
+	cache.ExpiryMargin = 0
+
+	calls := 0
+	fetch := func(scope string) (oauth.Bearer, error) {
+		calls++
+		return oauth.Bearer{AccessToken: scope, ExpiresIn: 0}, nil
+	}
+
+	cache.Token("key", "data:read", fetch)
+	cache.Token("key", "data:read", fetch)
+
+	if calls != 2 {
+		t.Errorf("Expected an expired token to be fetched again, got %d calls", calls)
+	}
+
+	failing := func(scope string) (oauth.Bearer, error) {
+		return oauth.Bearer{}, errors.New("[401] unauthorized")
+	}
+	if _, err := cache.Token("other", "data:read", failing); err == nil {
+		t.Error("Expected the fetch error to be returned")
+	}
+}
@@ -0,0 +1,296 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrReconsentRequired is returned by TokenManager for users whose refresh token was rejected,
+// who have to go through the 3-legged flow again
+var ErrReconsentRequired = errors.New("the user has to authorize the application again")
+
+// DefaultIdleTimeout is how long a TokenManager keeps the token of a user who makes no request
+const DefaultIdleTimeout = 30 * time.Minute
+
+// TokenManager holds the 3-legged tokens of many users, keyed by user ID, for backends calling the *3L APIs
+// on behalf of their users.
+//
+// Each token is refreshed in the background on its own, so refreshing the token of one user never waits
+// for another. Tokens unused for IdleTimeout are evicted from memory and, if the manager has a Store,
+// loaded again on the next request. Users whose refresh token is rejected are reported by NeedsReconsent
+// and OnReconsent until they log in again.
+type TokenManager struct {
+	Auth        ThreeLeggedAuth
+	Store       TokenStore    // Persists the tokens, may be nil to keep them in memory only
+	IdleTimeout time.Duration // Evicted users have to log in again when there is no Store
+	// OnReconsent, if set, is called when the refresh token of a user is rejected as invalid or expired
+	OnReconsent func(userID string, err error)
+
+	ctx       context.Context
+	cancel    context.CancelFunc
+	mutex     sync.Mutex // Only guards the maps, never held during requests to the authentication server
+	users     map[string]*managedToken
+	stopping  map[string][]*managedToken // Evicted or replaced tokens whose background refresh may still save a token
+	reconsent map[string]error
+	lastSweep time.Time
+}
+
+type managedToken struct {
+	lastUsed int64 // Unix nanoseconds, updated atomically. First field, so that it is 64-bit aligned on 32-bit platforms
+	token    *RefreshableToken
+	cancel   context.CancelFunc // Stops the background refresh
+	done     chan struct{}      // Closed once the background refresh stopped
+}
+
+// NewTokenManager returns a TokenManager refreshing tokens with auth and persisting them in store, which may be nil
+func NewTokenManager(auth ThreeLeggedAuth, store TokenStore) *TokenManager {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &TokenManager{
+		Auth:        auth,
+		Store:       store,
+		IdleTimeout: DefaultIdleTimeout,
+		ctx:         ctx,
+		cancel:      cancel,
+		users:       make(map[string]*managedToken),
+		stopping:    make(map[string][]*managedToken),
+		reconsent:   make(map[string]error),
+	}
+}
+
+// Add registers the token a user obtained by logging in with the given scopes,
+// replacing any previous token of the user
+func (m *TokenManager) Add(userID string, bearer Bearer, scopes Scopes) (*RefreshableToken, error) {
+	// The previous token must not save its own refresh after the new one is saved
+	m.mutex.Lock()
+	m.unregister(userID)
+	m.mutex.Unlock()
+	m.waitStopped(userID)
+
+	expiryTime := time.Now().Add(time.Second * time.Duration(bearer.ExpiresIn))
+
+	var token *RefreshableToken
+	if m.Store != nil {
+		var err error
+		if token, err = NewRefreshableTokenWithStore(&bearer, expiryTime, scopes, m.Store, userID); err != nil {
+			return nil, err
+		}
+	} else {
+		token = NewRefreshableTokenWithScope(&bearer, expiryTime, scopes)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.reconsent, userID)
+	m.register(userID, token)
+
+	return token, nil
+}
+
+// Token returns the token of the user, to be used as the TokenRefresher of the *3L APIs.
+// It returns an error wrapping ErrReconsentRequired if the user has to log in again
+// and ErrTokenNotFound if the user never logged in.
+func (m *TokenManager) Token(userID string) (*RefreshableToken, error) {
+	m.mutex.Lock()
+	m.sweep()
+	if err, ok := m.reconsent[userID]; ok {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("%w: %v", ErrReconsentRequired, err)
+	}
+	if user, ok := m.users[userID]; ok {
+		m.mutex.Unlock()
+		atomic.StoreInt64(&user.lastUsed, time.Now().UnixNano())
+		return user.token, nil
+	}
+	m.mutex.Unlock()
+
+	if m.Store == nil {
+		return nil, ErrTokenNotFound
+	}
+
+	// An evicted token may still be saving a rotated refresh token, which has to be the one loaded
+	m.waitStopped(userID)
+
+	// The token is loaded without holding the lock, so a slow store does not block the other users
+	token, err := LoadRefreshableToken(m.Store, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err, ok := m.reconsent[userID]; ok {
+		return nil, fmt.Errorf("%w: %v", ErrReconsentRequired, err)
+	}
+	if user, ok := m.users[userID]; ok {
+		// loaded concurrently by another request
+		return user.token, nil
+	}
+	m.register(userID, token)
+
+	return token, nil
+}
+
+// Remove forgets the user, e.g. when they log out, deleting the token from the Store
+func (m *TokenManager) Remove(userID string) error {
+	m.mutex.Lock()
+	m.unregister(userID)
+	delete(m.reconsent, userID)
+	m.mutex.Unlock()
+
+	if m.Store == nil {
+		return nil
+	}
+
+	// Otherwise a refresh in progress would save the token again
+	m.waitStopped(userID)
+
+	return m.Store.Delete(userID)
+}
+
+// NeedsReconsent returns the sorted IDs of the users whose refresh token was rejected since they last logged in
+func (m *TokenManager) NeedsReconsent() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	users := make([]string, 0, len(m.reconsent))
+	for userID := range m.reconsent {
+		users = append(users, userID)
+	}
+	sort.Strings(users)
+
+	return users
+}
+
+// EvictIdle removes from memory the tokens unused for IdleTimeout. It is also done periodically by Token.
+func (m *TokenManager) EvictIdle() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.evict(time.Now())
+}
+
+// Close stops the background refresh of all the tokens
+func (m *TokenManager) Close() {
+	m.cancel()
+}
+
+// register starts managing the token of the user, the caller must hold the lock
+func (m *TokenManager) register(userID string, token *RefreshableToken) {
+	m.unregister(userID)
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	previous := token.OnInvalidGrant
+	token.OnInvalidGrant = func(err error) {
+		m.invalidGrant(userID, token, err)
+		if previous != nil {
+			previous(err)
+		}
+	}
+	user := &managedToken{
+		token:    token,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		lastUsed: time.Now().UnixNano(),
+	}
+	m.users[userID] = user
+
+	go func() {
+		token.backgroundRefresh(ctx, m.Auth)
+
+		m.mutex.Lock()
+		m.stopped(userID, user)
+		m.mutex.Unlock()
+		close(user.done)
+	}()
+}
+
+// unregister stops managing the token of the user, which is kept in stopping until its background refresh
+// stopped. The caller must hold the lock.
+func (m *TokenManager) unregister(userID string) {
+	user, ok := m.users[userID]
+	if !ok {
+		return
+	}
+
+	user.cancel()
+	delete(m.users, userID)
+	m.stopping[userID] = append(m.stopping[userID], user)
+}
+
+// waitStopped waits for the background refresh of the unregistered tokens of the user to stop.
+// The lock must not be held, as a stopping refresh may need it to report a rejected refresh token.
+func (m *TokenManager) waitStopped(userID string) {
+	m.mutex.Lock()
+	stopping := m.stopping[userID]
+	m.mutex.Unlock()
+
+	for _, user := range stopping {
+		<-user.done
+	}
+}
+
+// stopped forgets the token once its background refresh stopped, the caller must hold the lock
+func (m *TokenManager) stopped(userID string, user *managedToken) {
+	var remaining []*managedToken
+	for _, other := range m.stopping[userID] {
+		if other != user {
+			remaining = append(remaining, other)
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(m.stopping, userID)
+	} else {
+		m.stopping[userID] = remaining
+	}
+}
+
+func (m *TokenManager) invalidGrant(userID string, token *RefreshableToken, err error) {
+	m.mutex.Lock()
+	user, ok := m.users[userID]
+	if !ok || user.token != token {
+		// the user logged in again in the meantime
+		m.mutex.Unlock()
+		return
+	}
+	m.unregister(userID)
+	m.reconsent[userID] = err
+	m.mutex.Unlock()
+
+	// The user may have logged in again since the refresh failed, replacing the stored token
+	if m.Store != nil {
+		if stored, err := m.Store.Load(userID); err == nil && stored.Bearer.RefreshToken == token.Bearer().RefreshToken {
+			m.Store.Delete(userID)
+		}
+	}
+	if m.OnReconsent != nil {
+		m.OnReconsent(userID, err)
+	}
+}
+
+// sweep evicts the idle tokens if it was not done recently, the caller must hold the lock
+func (m *TokenManager) sweep() {
+	now := time.Now()
+	if now.Sub(m.lastSweep) < m.IdleTimeout/2 {
+		return
+	}
+
+	m.evict(now)
+}
+
+func (m *TokenManager) evict(now time.Time) {
+	m.lastSweep = now
+	for userID, user := range m.users {
+		if now.Sub(time.Unix(0, atomic.LoadInt64(&user.lastUsed))) >= m.IdleTimeout {
+			m.unregister(userID)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package oauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenManager_registerKeepsOnInvalidGrant(t *testing.T) {
+
+	manager := NewTokenManager(ThreeLeggedAuth{}, nil)
+	defer manager.Close()
+
+	token := NewRefreshableTokenWithScope(&Bearer{AccessToken: "access", RefreshToken: "refresh"}, time.Now().Add(time.Hour), nil)
+	var reported error
+	token.OnInvalidGrant = func(err error) {
+		reported = err
+	}
+
+	manager.mutex.Lock()
+	manager.register("user", token)
+	manager.mutex.Unlock()
+
+	rejected := errors.New("invalid_grant")
+	token.OnInvalidGrant(rejected)
+
+	if reported != rejected {
+		t.Errorf("Expected the hook set before the token was managed to be called, got %v", reported)
+	}
+	if users := manager.NeedsReconsent(); len(users) != 1 || users[0] != "user" {
+		t.Errorf("Expected the user to need a new consent, got %v", users)
+	}
+}
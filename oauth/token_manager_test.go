@@ -0,0 +1,285 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// userRefreshServer rotates refresh tokens, except those named "revoked" which are rejected as invalid
+func userRefreshServer() (oauth.ThreeLeggedAuth, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("refresh_token") == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"The refresh token is invalid or expired."}`))
+			return
+		}
+		json.NewEncoder(w).Encode(oauth.Bearer{
+			AccessToken:  "refreshed-" + r.PostForm.Get("refresh_token"),
+			RefreshToken: r.PostForm.Get("refresh_token"),
+			ExpiresIn:    3599,
+		})
+	}))
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	auth.Host = server.URL
+
+	return auth, server.Close
+}
+
+func TestTokenManager(t *testing.T) {
+	auth, stop := userRefreshServer()
+	defer stop()
+
+	manager := oauth.NewTokenManager(auth, nil)
+	defer manager.Close()
+
+	added, err := manager.Add("alice", oauth.Bearer{AccessToken: "access", RefreshToken: "alice", ExpiresIn: 3599}, oauth.NewScopes(oauth.ScopeDataRead))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	token, err := manager.Token("alice")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token != added || token.GrantedScope() != "data:read" {
+		t.Error("Expected the added token to be returned")
+	}
+
+	if _, err = manager.Token("bob"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound for an unknown user, got %v", err)
+	}
+
+	if err = manager.Remove("alice"); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err = manager.Token("alice"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Errorf("Expected ErrTokenNotFound for a removed user, got %v", err)
+	}
+}
+
+func TestTokenManager_ConcurrentRefresh(t *testing.T) {
+	auth, stop := userRefreshServer()
+	defer stop()
+
+	manager := oauth.NewTokenManager(auth, nil)
+	defer manager.Close()
+
+	const users = 20
+	for i := 0; i < users; i++ {
+		userID := fmt.Sprintf("user-%d", i)
+		if _, err := manager.Add(userID, oauth.Bearer{AccessToken: "expired", RefreshToken: userID}, oauth.NewScopes(oauth.ScopeDataRead)); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	var wait sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wait.Add(1)
+		go func(userID string) {
+			defer wait.Done()
+			token, err := manager.Token(userID)
+			if err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if err = token.RefreshTokenIfRequired(auth); err != nil {
+				t.Error(err.Error())
+				return
+			}
+			if token.Bearer().AccessToken != "refreshed-"+userID {
+				t.Errorf("Unexpected token for %s: %s", userID, token.Bearer().AccessToken)
+			}
+		}(fmt.Sprintf("user-%d", i))
+	}
+	wait.Wait()
+}
+
+func TestTokenManager_Reconsent(t *testing.T) {
+	auth, stop := userRefreshServer()
+	defer stop()
+
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	store, err := oauth.NewFileTokenStore(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	manager := oauth.NewTokenManager(auth, store)
+	defer manager.Close()
+
+	reported := make(chan string, 1)
+	manager.OnReconsent = func(userID string, err error) {
+		reported <- userID
+	}
+
+	if _, err = manager.Add("alice", oauth.Bearer{AccessToken: "expired", RefreshToken: "revoked"}, oauth.NewScopes(oauth.ScopeDataRead)); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	select {
+	case userID := <-reported:
+		if userID != "alice" {
+			t.Errorf("Expected alice to be reported, got %s", userID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the rejected refresh token to be reported")
+	}
+
+	if users := manager.NeedsReconsent(); len(users) != 1 || users[0] != "alice" {
+		t.Errorf("Expected alice to need to re-consent, got %v", users)
+	}
+	if _, err = manager.Token("alice"); !errors.Is(err, oauth.ErrReconsentRequired) {
+		t.Errorf("Expected ErrReconsentRequired, got %v", err)
+	}
+	if _, err = store.Load("alice"); !errors.Is(err, oauth.ErrTokenNotFound) {
+		t.Errorf("Expected the rejected token to be deleted from the store, got %v", err)
+	}
+
+	if _, err = manager.Add("alice", oauth.Bearer{AccessToken: "access", RefreshToken: "alice", ExpiresIn: 3599}, oauth.NewScopes(oauth.ScopeDataRead)); err != nil {
+		t.Fatal(err.Error())
+	}
+	if users := manager.NeedsReconsent(); len(users) != 0 {
+		t.Errorf("Expected logging in again to clear the re-consent, got %v", users)
+	}
+}
+
+func TestTokenManager_EvictIdle(t *testing.T) {
+	auth, stop := userRefreshServer()
+	defer stop()
+
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	store, err := oauth.NewFileTokenStore(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	manager := oauth.NewTokenManager(auth, store)
+	defer manager.Close()
+	manager.IdleTimeout = 10 * time.Millisecond
+
+	added, err := manager.Add("alice", oauth.Bearer{AccessToken: "access", RefreshToken: "alice", ExpiresIn: 3599}, oauth.NewScopes(oauth.ScopeDataRead))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	manager.EvictIdle()
+
+	token, err := manager.Token("alice")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token == added {
+		t.Error("Expected the idle token to be evicted")
+	}
+	if token.Bearer().AccessToken != "access" || token.GrantedScope() != "data:read" {
+		t.Errorf("Expected the evicted token to be loaded from the store, got %+v", token.Bearer())
+	}
+}
+
+func TestTokenManager_RefreshDuringEviction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	store, err := oauth.NewFileTokenStore(dir)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// the refresh of alice's token is still in progress when it is evicted, while bob logs in again
+	// on another replica while his refresh token is being rejected
+	var aliceRefreshes int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.PostForm.Get("refresh_token") {
+		case "alice":
+			if atomic.AddInt32(&aliceRefreshes, 1) == 1 {
+				close(started)
+				<-release
+				json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "refreshed", RefreshToken: "alice-rotated", ExpiresIn: 3599})
+				return
+			}
+			// the refresh token was already used
+			fallthrough
+		case "revoked":
+			if r.PostForm.Get("refresh_token") == "revoked" {
+				store.Save("bob", oauth.StoredToken{Bearer: oauth.Bearer{AccessToken: "access", RefreshToken: "bob"}})
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"The refresh token is invalid or expired."}`))
+		}
+	}))
+	defer server.Close()
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	auth.Host = server.URL
+
+	manager := oauth.NewTokenManager(auth, store)
+	defer manager.Close()
+	manager.IdleTimeout = 10 * time.Millisecond
+
+	if _, err = manager.Add("alice", oauth.Bearer{AccessToken: "expired", RefreshToken: "alice"}, oauth.NewScopes(oauth.ScopeDataRead)); err != nil {
+		t.Fatal(err.Error())
+	}
+	<-started
+	time.Sleep(20 * time.Millisecond)
+	manager.EvictIdle()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	token, err := manager.Token("alice")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.Bearer().RefreshToken != "alice-rotated" {
+		t.Errorf("Expected the token rotated during the eviction to be loaded, got %s", token.Bearer().RefreshToken)
+	}
+
+	t.Run("Login during rejection", func(t *testing.T) {
+		reported := make(chan string, 1)
+		manager.OnReconsent = func(userID string, err error) {
+			reported <- userID
+		}
+
+		if _, err = manager.Add("bob", oauth.Bearer{AccessToken: "expired", RefreshToken: "revoked"}, oauth.NewScopes(oauth.ScopeDataRead)); err != nil {
+			t.Fatal(err.Error())
+		}
+		select {
+		case <-reported:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the rejected refresh token to be reported")
+		}
+
+		if stored, err := store.Load("bob"); err != nil || stored.Bearer.RefreshToken != "bob" {
+			t.Errorf("Expected the token of the new login to be kept, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package oauth
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenSource returns an oauth2.TokenSource providing tokens with the given scope, e.g. to be used with oauth2.Transport.
+// The tokens are reused until shortly before they expire, a token taken from the Cache keeping the expiry
+// it was issued with.
+func (a TwoLeggedAuth) TokenSource(scope string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, twoLeggedTokenSource{a, scope})
+}
+
+type twoLeggedTokenSource struct {
+	auth  TwoLeggedAuth
+	scope string
+}
+
+func (s twoLeggedTokenSource) Token() (*oauth2.Token, error) {
+	bearer, expiry, err := s.auth.authenticateWithExpiry(s.scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOAuth2Token(bearer, expiry), nil
+}
+
+// TokenSource returns an oauth2.TokenSource providing the access token, refreshed with auth when required
+func (t *RefreshableToken) TokenSource(auth ThreeLeggedAuth) oauth2.TokenSource {
+	return refreshableTokenSource{t, auth}
+}
+
+type refreshableTokenSource struct {
+	token *RefreshableToken
+	auth  ThreeLeggedAuth
+}
+
+func (s refreshableTokenSource) Token() (*oauth2.Token, error) {
+	if err := s.token.RefreshTokenIfRequired(s.auth); err != nil {
+		return nil, err
+	}
+
+	return toOAuth2Token(*s.token.Bearer(), s.token.ExpiryTime()), nil
+}
+
+func toOAuth2Token(bearer Bearer, expiry time.Time) *oauth2.Token {
+	token := &oauth2.Token{
+		AccessToken:  bearer.AccessToken,
+		TokenType:    bearer.TokenType,
+		RefreshToken: bearer.RefreshToken,
+		Expiry:       expiry,
+	}
+	if len(bearer.IDToken) != 0 {
+		token = token.WithExtra(map[string]interface{}{"id_token": bearer.IDToken})
+	}
+
+	return token
+}
+
+// TokenSourceRefresher provides the tokens of an oauth2.TokenSource to the 3-legged APIs of the dm and md packages,
+// which expect a token with the Bearer and RefreshTokenIfRequired methods.
+// The refreshing is left to the TokenSource, so the ThreeLeggedAuth passed to RefreshTokenIfRequired is not used.
+type TokenSourceRefresher struct {
+	source oauth2.TokenSource
+	mutex  sync.Mutex
+	bearer *Bearer
+}
+
+// NewTokenSourceRefresher returns a TokenSourceRefresher getting its tokens from source,
+// which is wrapped with oauth2.ReuseTokenSource so that tokens are reused until they expire
+func NewTokenSourceRefresher(source oauth2.TokenSource) *TokenSourceRefresher {
+	return &TokenSourceRefresher{
+		source: oauth2.ReuseTokenSource(nil, source),
+		bearer: &Bearer{},
+	}
+}
+
+// RefreshTokenIfRequired gets the current token from the TokenSource
+func (r *TokenSourceRefresher) RefreshTokenIfRequired(auth ThreeLeggedAuth) error {
+	token, err := r.source.Token()
+	if err != nil {
+		return err
+	}
+
+	bearer := &Bearer{
+		TokenType:    token.Type(),
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+	}
+	if !token.Expiry.IsZero() {
+		bearer.ExpiresIn = int32(time.Until(token.Expiry) / time.Second)
+	}
+	if idToken, ok := token.Extra("id_token").(string); ok {
+		bearer.IDToken = idToken
+	}
+
+	r.mutex.Lock()
+	r.bearer = bearer
+	r.mutex.Unlock()
+
+	return nil
+}
+
+// Bearer returns the token obtained by the last call to RefreshTokenIfRequired
+func (r *TokenSourceRefresher) Bearer() *Bearer {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.bearer
+}
@@ -0,0 +1,109 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/outer-labs/forge-api-go-client/dm"
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestTwoLeggedAuth_TokenSource(t *testing.T) {
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authentication/v2/token":
+			requests++
+			json.NewEncoder(w).Encode(oauth.Bearer{TokenType: "Bearer", AccessToken: "access", ExpiresIn: 3599})
+		case "/api":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	auth := oauth.NewTwoLeggedClientV2("client", "secret")
+	auth.Host = server.URL
+
+	// the token is cached before the TokenSource asks for it, and must keep its original expiry
+	if _, err := auth.Authenticate("data:read"); err != nil {
+		t.Fatal(err.Error())
+	}
+	time.Sleep(1100 * time.Millisecond)
+
+	source := auth.TokenSource("data:read")
+
+	token, err := source.Token()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if token.AccessToken != "access" || !token.Valid() {
+		t.Errorf("Unexpected token: %+v", token)
+	}
+	if expiry := time.Until(token.Expiry); expiry < 59*time.Minute || expiry > 3598*time.Second {
+		t.Errorf("Expected the expiry of the cached token, issued a second ago, got %s", expiry)
+	}
+
+	client := &http.Client{Transport: &oauth2.Transport{Source: source}}
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(server.URL + "/api")
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("Expected the token to be sent, got %d", response.StatusCode)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected the token to be reused, got %d token requests", requests)
+	}
+}
+
+func TestRefreshableToken_TokenSource(t *testing.T) {
+
+	bearer := &oauth.Bearer{TokenType: "Bearer", AccessToken: "access", RefreshToken: "refresh"}
+	expiry := time.Now().Add(time.Hour)
+	token := oauth.NewRefreshableToken(bearer, expiry)
+
+	result, err := token.TokenSource(oauth.ThreeLeggedAuth{}).Token()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.AccessToken != "access" || result.RefreshToken != "refresh" || !result.Expiry.Equal(expiry) {
+		t.Errorf("Unexpected token: %+v", result)
+	}
+}
+
+func TestTokenSourceRefresher(t *testing.T) {
+
+	source := oauth2.StaticTokenSource((&oauth2.Token{
+		AccessToken: "access",
+		TokenType:   "Bearer",
+		Expiry:      time.Now().Add(time.Hour),
+	}).WithExtra(map[string]interface{}{"id_token": "id"}))
+
+	var refresher dm.TokenRefresher = oauth.NewTokenSourceRefresher(source)
+
+	if err := refresher.RefreshTokenIfRequired(oauth.ThreeLeggedAuth{}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	bearer := refresher.Bearer()
+	if bearer.AccessToken != "access" || bearer.TokenType != "Bearer" || bearer.IDToken != "id" {
+		t.Errorf("Unexpected bearer: %+v", bearer)
+	}
+	if bearer.ExpiresIn < 3590 || bearer.ExpiresIn > 3600 {
+		t.Errorf("Expected the expiry to be converted to ExpiresIn, got %d", bearer.ExpiresIn)
+	}
+}
@@ -0,0 +1,177 @@
+package oauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrTokenNotFound is returned by a TokenStore when nothing is stored under the requested key
+var ErrTokenNotFound = errors.New("token not found")
+
+// StoredToken reflects what a TokenStore persists for a user or session
+type StoredToken struct {
+	Bearer    Bearer    `json:"bearer"`
+	ExpiresAt time.Time `json:"expires_at"`      // Calculated expiration time of the access token
+	Scope     string    `json:"scope,omitempty"` // The space-separated scopes granted by the user
+}
+
+// TokenStore persists the tokens of a RefreshableToken, keyed by a user or session ID,
+// so that a rotated refresh token is not lost when the process restarts.
+//
+// Implementations must be safe for concurrent use and Save must replace the stored token atomically:
+// a concurrent or interrupted Save must never leave a partially written token behind.
+type TokenStore interface {
+	Load(key string) (StoredToken, error) // Returns ErrTokenNotFound if nothing is stored under the key
+	Save(key string, token StoredToken) error
+	Delete(key string) error
+}
+
+// FileTokenStore is a TokenStore keeping each token as a JSON file in a directory,
+// optionally encrypted with AES-GCM.
+type FileTokenStore struct {
+	Dir   string
+	aead  cipher.AEAD
+	mutex sync.Mutex
+}
+
+// NewFileTokenStore returns a TokenStore writing plain JSON files into dir, which is created if missing.
+//
+//	Note: the files contain refresh tokens in clear, consider NewEncryptedFileTokenStore
+//	if the directory is not private to the process.
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &FileTokenStore{Dir: dir}, nil
+}
+
+// NewEncryptedFileTokenStore returns a TokenStore writing files into dir encrypted with AES-GCM.
+// The key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewEncryptedFileTokenStore(dir string, key []byte) (*FileTokenStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := NewFileTokenStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	store.aead = aead
+
+	return store, nil
+}
+
+// Load reads the token stored under key
+func (s *FileTokenStore) Load(key string) (token StoredToken, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	content, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		err = ErrTokenNotFound
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	if s.aead != nil {
+		if content, err = s.decrypt(content); err != nil {
+			return
+		}
+	}
+
+	err = json.Unmarshal(content, &token)
+
+	return
+}
+
+// Save replaces the token stored under key by writing a temporary file and renaming it over the previous one
+func (s *FileTokenStore) Save(key string, token StoredToken) error {
+	content, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	if s.aead != nil {
+		if content, err = s.encrypt(content); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := ioutil.TempFile(s.Dir, ".token-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err = file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.path(key))
+}
+
+// Delete removes the token stored under key. Deleting a missing token is not an error.
+func (s *FileTokenStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// path encodes the key, so that any user or session ID results in a valid file name inside Dir
+func (s *FileTokenStore) path(key string) string {
+	return filepath.Join(s.Dir, base64.RawURLEncoding.EncodeToString([]byte(key))+".json")
+}
+
+func (s *FileTokenStore) encrypt(content []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return s.aead.Seal(nonce, nonce, content, nil), nil
+}
+
+func (s *FileTokenStore) decrypt(content []byte) ([]byte, error) {
+	size := s.aead.NonceSize()
+	if len(content) < size {
+		return nil, errors.New("the stored token is corrupted")
+	}
+
+	return s.aead.Open(nil, content[:size], content[size:], nil)
+}
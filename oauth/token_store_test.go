@@ -0,0 +1,109 @@
+package oauth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+func TestFileTokenStore(t *testing.T) {
+
+	plain, err := oauth.NewFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	encrypted, err := oauth.NewEncryptedFileTokenStore(t.TempDir(), bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	for name, store := range map[string]*oauth.FileTokenStore{"plain": plain, "encrypted": encrypted} {
+		t.Run(name, func(t *testing.T) {
+			key := "user/with:odd chars"
+
+			if _, err := store.Load(key); err != oauth.ErrTokenNotFound {
+				t.Fatalf("Expected ErrTokenNotFound, got %v", err)
+			}
+
+			saved := oauth.StoredToken{
+				Bearer:    oauth.Bearer{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3599},
+				ExpiresAt: time.Now().Add(time.Hour).Round(time.Second),
+			}
+			if err := store.Save(key, saved); err != nil {
+				t.Fatal(err.Error())
+			}
+
+			loaded, err := store.Load(key)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if loaded.Bearer != saved.Bearer || !loaded.ExpiresAt.Equal(saved.ExpiresAt) {
+				t.Errorf("Expected %+v, loaded %+v", saved, loaded)
+			}
+
+			files, _ := filepath.Glob(filepath.Join(store.Dir, "*"))
+			if len(files) != 1 {
+				t.Errorf("Expected a single file without leftovers, got %v", files)
+			}
+			content, _ := ioutil.ReadFile(files[0])
+			if isEncrypted := !bytes.Contains(content, []byte("refresh")); isEncrypted != (name == "encrypted") {
+				t.Errorf("Unexpected file content: %s", content)
+			}
+
+			if err := store.Delete(key); err != nil {
+				t.Fatal(err.Error())
+			}
+			if _, err := store.Load(key); err != oauth.ErrTokenNotFound {
+				t.Errorf("Expected ErrTokenNotFound after delete, got %v", err)
+			}
+		})
+	}
+
+	if _, err := oauth.NewEncryptedFileTokenStore(t.TempDir(), []byte("short")); err == nil {
+		t.Error("Expected an invalid key size to be rejected")
+	}
+}
+
+func TestRefreshableToken_SavesRotatedToken(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "new-access", RefreshToken: "new-refresh", ExpiresIn: 3599})
+	}))
+	defer server.Close()
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost:3009/callback")
+	auth.Host = server.URL
+
+	store, err := oauth.NewFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expired := &oauth.Bearer{AccessToken: "old-access", RefreshToken: "old-refresh"}
+	token, err := oauth.NewRefreshableTokenWithStore(expired, time.Now().Add(-time.Minute), oauth.NewScopes(oauth.ScopeDataRead, oauth.ScopeDataWrite), store, "user")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	if err := token.RefreshTokenIfRequired(auth); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	restored, err := oauth.LoadRefreshableToken(store, "user")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if restored.Bearer().RefreshToken != "new-refresh" || !restored.TokenExpireTime.After(time.Now()) {
+		t.Errorf("Expected the rotated token to be stored, got %+v", restored.Bearer())
+	}
+	if restored.GrantedScope() != "data:read data:write" {
+		t.Errorf("Expected the granted scope to be stored, got %s", restored.GrantedScope())
+	}
+}
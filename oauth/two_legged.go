@@ -1,19 +1,15 @@
 package oauth
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
-	"io/ioutil"
-	"net/http"
 	"net/url"
-	"strconv"
 	"time"
 )
 
 // TwoLeggedAuth struct holds data necessary for making requests in 2-legged context
 type TwoLeggedAuth struct {
 	AuthData
+	// Cache holds the tokens obtained by Authenticate. When nil, every call requests a new token.
+	Cache *TokenCache `json:"-"`
 }
 
 // TwoLeggedAuthenticator interface defines the method necessary to qualify as 2-legged authenticator
@@ -24,51 +20,66 @@ type TwoLeggedAuthenticator interface {
 // NewTwoLeggedClient returns a 2-legged authenticator with default host and authPath
 func NewTwoLeggedClient(clientID, clientSecret string) TwoLeggedAuth {
 	return TwoLeggedAuth{
-		AuthData{
-			clientID,
-			clientSecret,
-			"https://developer.api.autodesk.com",
-			"/authentication/v1",
-			time.Now(),
-		},
+		newAuthData(clientID, clientSecret, AuthV1),
+		NewTokenCache(),
 	}
 }
 
-// Authenticate allows getting a token with a given scope
-func (a TwoLeggedAuth) Authenticate(scope string) (bearer Bearer, err error) {
+// NewTwoLeggedClientV2 returns a 2-legged authenticator using the v2 Authentication API
+func NewTwoLeggedClientV2(clientID, clientSecret string) TwoLeggedAuth {
+	return TwoLeggedAuth{
+		newAuthData(clientID, clientSecret, AuthV2),
+		NewTokenCache(),
+	}
+}
 
-	task := http.Client{}
+// NewTwoLeggedClientWithProvider returns a 2-legged authenticator using the v2 Authentication API,
+// reading its credentials from provider on every token request
+func NewTwoLeggedClientWithProvider(provider CredentialsProvider) TwoLeggedAuth {
+	auth := NewTwoLeggedClientV2("", "")
+	auth.Credentials = provider
 
-	body := url.Values{}
-	body.Add("client_id", a.ClientID)
-	body.Add("client_secret", a.ClientSecret)
-	body.Add("grant_type", "client_credentials")
-	body.Add("scope", scope)
+	return auth
+}
 
-	req, err := http.NewRequest("POST",
-		a.Host+a.AuthPath+"/authenticate",
-		bytes.NewBufferString(body.Encode()),
-	)
+// Authenticate allows getting a token with a given scope.
+// If the client has a Cache, a previously obtained token for the same scope is reused until it is about to expire.
+// Unknown scopes are rejected with an error wrapping ErrInvalidScope, without querying the server.
+func (a TwoLeggedAuth) Authenticate(scope string) (bearer Bearer, err error) {
+	bearer, _, err = a.authenticateWithExpiry(scope)
+	return
+}
 
-	if err != nil {
+// authenticateWithExpiry works as Authenticate, also returning when the bearer expires
+func (a TwoLeggedAuth) authenticateWithExpiry(scope string) (bearer Bearer, expiry time.Time, err error) {
+	if _, err = ParseScopes(scope); err != nil {
 		return
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	response, err := task.Do(req)
 
-	if err != nil {
-		return
+	if a.Cache == nil {
+		requestedAt := time.Now()
+		bearer, err = a.authenticate(scope)
+		return bearer, requestedAt.Add(time.Duration(bearer.ExpiresIn) * time.Second), err
 	}
-	defer response.Body.Close()
 
-	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+	credentials, err := a.credentials()
+	if err != nil {
 		return
 	}
 
-	decoder := json.NewDecoder(response.Body)
-	err = decoder.Decode(&bearer)
+	return a.Cache.token(credentials.ClientID, scope, a.authenticate)
+}
 
-	return
+// AuthenticateWithScopes works as Authenticate, for a set of scopes
+func (a TwoLeggedAuth) AuthenticateWithScopes(scopes Scopes) (Bearer, error) {
+	return a.Authenticate(scopes.String())
+}
+
+func (a TwoLeggedAuth) authenticate(scope string) (bearer Bearer, err error) {
+
+	body := url.Values{}
+	body.Add("grant_type", "client_credentials")
+	body.Add("scope", scope)
+
+	return a.requestToken("/authenticate", body)
 }
@@ -1,12 +1,17 @@
 package oauth_test
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 
-	"github.com/apprentice3d/forge-api-go-client/oauth"
+	"github.com/outer-labs/forge-api-go-client/oauth"
 )
 
 func TestAuthenticate(t *testing.T) {
@@ -76,6 +81,82 @@ func TestAuthenticate(t *testing.T) {
 	})
 }
 
+func TestTwoLeggedAuth_AuthenticateVersions(t *testing.T) {
+
+	var request *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		request = r
+		json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "token", ExpiresIn: 3599})
+	}))
+	defer server.Close()
+
+	t.Run("v1 sends the secret in the body", func(t *testing.T) {
+		authenticator := oauth.NewTwoLeggedClient("client", "secret")
+		authenticator.Host = server.URL
+
+		if _, err := authenticator.Authenticate("data:read"); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if request.URL.Path != "/authentication/v1/authenticate" {
+			t.Errorf("Unexpected path: %s", request.URL.Path)
+		}
+		if request.PostForm.Get("client_id") != "client" || request.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("Expected the credentials in the body, got %v", request.PostForm)
+		}
+		if _, _, ok := request.BasicAuth(); ok {
+			t.Error("Did not expect HTTP Basic authentication in v1")
+		}
+	})
+
+	t.Run("v2 uses HTTP Basic and the token endpoint", func(t *testing.T) {
+		authenticator := oauth.NewTwoLeggedClientV2("client", "secret")
+		authenticator.Host = server.URL
+
+		if _, err := authenticator.Authenticate("data:read"); err != nil {
+			t.Fatal(err.Error())
+		}
+
+		if request.URL.Path != "/authentication/v2/token" {
+			t.Errorf("Unexpected path: %s", request.URL.Path)
+		}
+		if id, secret, ok := request.BasicAuth(); !ok || id != "client" || secret != "secret" {
+			t.Errorf("Expected HTTP Basic credentials, got %s:%s", id, secret)
+		}
+		if request.PostForm.Get("client_secret") != "" {
+			t.Error("The client secret should not be sent in the body")
+		}
+		if request.PostForm.Get("grant_type") != "client_credentials" {
+			t.Errorf("Unexpected grant type: %s", request.PostForm.Get("grant_type"))
+		}
+	})
+
+	t.Run("Rate limiter", func(t *testing.T) {
+		limiter := &countingLimiter{}
+		authenticator := oauth.NewTwoLeggedClient("client", "secret")
+		authenticator.Host = server.URL
+		authenticator.RateLimiter = limiter
+
+		if _, err := authenticator.Authenticate("data:read"); err != nil {
+			t.Fatal(err.Error())
+		}
+		if limiter.requests != 1 {
+			t.Errorf("Expected the request to go through the limiter, got %d", limiter.requests)
+		}
+	})
+}
+
+// countingLimiter records the requests made through it
+type countingLimiter struct {
+	requests int
+}
+
+func (l *countingLimiter) HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	l.requests++
+	return http.NewRequest(method, url, body)
+}
+
 func ExampleTwoLeggedAuth_Authenticate() {
 
 	// aquire Forge secrets from environment
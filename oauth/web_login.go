@@ -0,0 +1,238 @@
+package oauth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrStateInvalid is returned when the state received on the callback was not created by this application
+	ErrStateInvalid = errors.New("the state received on the callback is invalid")
+	// ErrStateExpired is returned when the user took longer than WebLogin.StateTTL to log in
+	ErrStateExpired = errors.New("the state received on the callback has expired")
+	// ErrStateReplayed is returned when a state is received on the callback more than once
+	ErrStateReplayed = errors.New("the state received on the callback was already used")
+
+	// errNoLoginHook is returned by the callback handler of a WebLogin without OnLogin
+	errNoLoginHook = errors.New("WebLogin.OnLogin is not set")
+)
+
+// DefaultStateTTL is how long a user has to complete the login started by WebLogin
+const DefaultStateTTL = 10 * time.Minute
+
+// MinWebLoginKeySize is the minimum size of the key signing the states of a WebLogin, that of a SHA-256 digest
+const MinWebLoginKeySize = 32
+
+// LoginHook is called by the callback handler of WebLogin once the user has logged in.
+// It is responsible for writing the response, e.g. by starting a session and redirecting to the application.
+type LoginHook func(w http.ResponseWriter, r *http.Request, bearer Bearer, profile UserProfile)
+
+// WebLogin provides the net/http handlers for the 3-legged flow of a web application.
+//
+// The login handler creates a random state, signed with the WebLogin key, stores it in a cookie and redirects
+// to the URL built by Authorize. The callback handler checks the received state against the signature, the cookie
+// and the states already used, exchanges the code with GetToken, gets the user profile with Information.AboutMe
+// and calls OnLogin.
+//
+// The states already used are only known to the WebLogin that received them. Instances sharing the key accept
+// each other's states, so a state could be received once by each of them before it expires. The cookie still binds
+// the state to the browser that started the login, and the authorization code it comes with can only be exchanged once.
+type WebLogin struct {
+	Auth        ThreeLeggedAuth
+	Scope       string      // The space-separated scopes requested
+	Information Information // Used to get the profile of the user who logged in
+	OnLogin     LoginHook   // Required, the callback handler fails with status 500 without it
+	// OnError writes the response when the callback fails. By default it answers with a plain text error,
+	// with status 400 for state errors, 500 without OnLogin and 502 when the authentication server could not be queried.
+	OnError    func(w http.ResponseWriter, r *http.Request, err error)
+	StateTTL   time.Duration // How long a user has to complete the login
+	CookieName string        // The name of the cookie binding the state to the browser
+
+	key   []byte
+	mutex sync.Mutex
+	used  map[string]time.Time // The states already received, until they expire
+}
+
+// NewWebLogin returns a WebLogin signing its states with key, which must be at least MinWebLoginKeySize random bytes.
+// The instances of the application sharing the key can complete the logins started by each other.
+func NewWebLogin(auth ThreeLeggedAuth, scope string, key []byte, onLogin LoginHook) (*WebLogin, error) {
+	if len(key) < MinWebLoginKeySize {
+		return nil, fmt.Errorf("the WebLogin key must be at least %d bytes, got %d", MinWebLoginKeySize, len(key))
+	}
+
+	return &WebLogin{
+		Auth:        auth,
+		Scope:       scope,
+		Information: NewInformationQuerier(),
+		OnLogin:     onLogin,
+		StateTTL:    DefaultStateTTL,
+		CookieName:  "forge_oauth_state",
+		key:         key,
+		used:        make(map[string]time.Time),
+	}, nil
+}
+
+// LoginHandler returns the handler starting the login
+func (l *WebLogin) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := l.newState()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		link, err := l.Auth.Authorize(l.Scope, state)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     l.CookieName,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   int(l.StateTTL / time.Second),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, link, http.StatusFound)
+	})
+}
+
+// CallbackHandler returns the handler to be served at the redirect URI of the application
+func (l *WebLogin) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the state cookie is single use
+		http.SetCookie(w, &http.Cookie{Name: l.CookieName, Path: "/", MaxAge: -1})
+
+		query := r.URL.Query()
+		if reason := query.Get("error"); len(reason) != 0 {
+			l.fail(w, r, fmt.Errorf("%w: %s %s", ErrAuthorizationFailed, reason, query.Get("error_description")))
+			return
+		}
+
+		if l.OnLogin == nil {
+			l.fail(w, r, errNoLoginHook)
+			return
+		}
+
+		code := query.Get("code")
+		if len(code) == 0 {
+			l.fail(w, r, fmt.Errorf("%w: no authorization code received", ErrAuthorizationFailed))
+			return
+		}
+
+		if err := l.checkState(r, query.Get("state")); err != nil {
+			l.fail(w, r, err)
+			return
+		}
+
+		bearer, err := l.Auth.GetToken(code)
+		if err != nil {
+			l.fail(w, r, err)
+			return
+		}
+
+		profile, err := l.Information.AboutMe(bearer.AccessToken)
+		if err != nil {
+			l.fail(w, r, err)
+			return
+		}
+
+		l.OnLogin(w, r, bearer, profile)
+	})
+}
+
+// newState returns a random nonce followed by its expiry time, signed with the key
+func (l *WebLogin) newState() (string, error) {
+	payload := make([]byte, 24)
+	if _, err := rand.Read(payload[:16]); err != nil {
+		return "", err
+	}
+	binary.BigEndian.PutUint64(payload[16:], uint64(time.Now().Add(l.StateTTL).Unix()))
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(l.sign(payload)), nil
+}
+
+// checkState verifies the signature and expiry of the state, that it matches the cookie set by the login handler,
+// so the login was started from the same browser, and that it was not used before
+func (l *WebLogin) checkState(r *http.Request, state string) error {
+	cookie, err := r.Cookie(l.CookieName)
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(state)) {
+		return ErrStateMismatch
+	}
+
+	parts := strings.Split(state, ".")
+	if len(parts) != 2 {
+		return ErrStateInvalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(payload) != 24 {
+		return ErrStateInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, l.sign(payload)) {
+		return ErrStateInvalid
+	}
+
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(payload[16:])), 0)
+	now := time.Now()
+	if now.After(expiry) {
+		return ErrStateExpired
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for used, usedExpiry := range l.used {
+		if now.After(usedExpiry) {
+			delete(l.used, used)
+		}
+	}
+	if _, ok := l.used[state]; ok {
+		return ErrStateReplayed
+	}
+	l.used[state] = expiry
+
+	return nil
+}
+
+func (l *WebLogin) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, l.key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func (l *WebLogin) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if l.OnError != nil {
+		l.OnError(w, r, err)
+		return
+	}
+
+	status := http.StatusBadGateway
+	if isStateError(err) {
+		status = http.StatusBadRequest
+	} else if err == errNoLoginHook {
+		status = http.StatusInternalServerError
+	}
+	http.Error(w, err.Error(), status)
+}
+
+func isStateError(err error) bool {
+	for _, stateErr := range []error{ErrAuthorizationFailed, ErrStateMismatch, ErrStateInvalid, ErrStateExpired, ErrStateReplayed} {
+		if errors.Is(err, stateErr) {
+			return true
+		}
+	}
+	return false
+}
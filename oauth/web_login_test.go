@@ -0,0 +1,198 @@
+package oauth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// fakeForge serves the token endpoint and the profile of the user
+func fakeForge() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authentication/v2/token":
+			r.ParseForm()
+			if r.PostForm.Get("code") != "the-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(oauth.Bearer{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 3599})
+		case "/userprofile/v1/users/@me":
+			if r.Header.Get("Authorization") != "Bearer access" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(oauth.UserProfile{UserID: "user-id", UserName: "user"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func newTestWebLogin(t *testing.T, host string, logins *[]oauth.UserProfile) *oauth.WebLogin {
+	auth := oauth.NewThreeLeggedClientV2("client", "secret", "http://localhost/callback")
+	auth.Host = host
+
+	login, err := oauth.NewWebLogin(auth, "data:read", []byte("0123456789abcdef0123456789abcdef"),
+		func(w http.ResponseWriter, r *http.Request, bearer oauth.Bearer, profile oauth.UserProfile) {
+			if bearer.AccessToken != "access" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			*logins = append(*logins, profile)
+			http.Redirect(w, r, "/", http.StatusFound)
+		})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	login.Information.Host = host
+
+	return login
+}
+
+// startLogin goes through the login handler and returns the state cookie and the state sent to Authorize
+func startLogin(t *testing.T, login *oauth.WebLogin) (*http.Cookie, string) {
+	recorder := httptest.NewRecorder()
+	login.LoginHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/login", nil))
+
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("Expected a redirection, got %d", recorder.Code)
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if location.Path != "/authentication/v2/authorize" {
+		t.Errorf("Expected a redirection to the authorization page, got %s", location)
+	}
+
+	cookies := recorder.Result().Cookies()
+	if len(cookies) != 1 || !cookies[0].HttpOnly {
+		t.Fatalf("Expected an HttpOnly state cookie, got %+v", cookies)
+	}
+
+	return cookies[0], location.Query().Get("state")
+}
+
+func callback(login *oauth.WebLogin, cookie *http.Cookie, query url.Values) int {
+	request := httptest.NewRequest("GET", "/callback?"+query.Encode(), nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	recorder := httptest.NewRecorder()
+	login.CallbackHandler().ServeHTTP(recorder, request)
+
+	return recorder.Code
+}
+
+func TestWebLogin(t *testing.T) {
+
+	server := fakeForge()
+	defer server.Close()
+
+	var logins []oauth.UserProfile
+	login := newTestWebLogin(t, server.URL, &logins)
+
+	cookie, state := startLogin(t, login)
+	if cookie.Value != state {
+		t.Error("Expected the cookie to hold the state")
+	}
+
+	query := url.Values{"code": {"the-code"}, "state": {state}}
+	if code := callback(login, cookie, query); code != http.StatusFound {
+		t.Fatalf("Expected the login hook to redirect, got %d", code)
+	}
+	if len(logins) != 1 || logins[0].UserID != "user-id" {
+		t.Fatalf("Expected the login hook to receive the profile, got %+v", logins)
+	}
+
+	t.Run("Replayed state", func(t *testing.T) {
+		if code := callback(login, cookie, query); code != http.StatusBadRequest {
+			t.Errorf("Expected a replayed state to be rejected, got %d", code)
+		}
+		if len(logins) != 1 {
+			t.Error("Expected no other login")
+		}
+	})
+}
+
+func TestWebLogin_InvalidState(t *testing.T) {
+
+	server := fakeForge()
+	defer server.Close()
+
+	var logins []oauth.UserProfile
+	login := newTestWebLogin(t, server.URL, &logins)
+
+	t.Run("Missing cookie", func(t *testing.T) {
+		_, state := startLogin(t, login)
+		if code := callback(login, nil, url.Values{"code": {"the-code"}, "state": {state}}); code != http.StatusBadRequest {
+			t.Errorf("Expected a state without cookie to be rejected, got %d", code)
+		}
+	})
+
+	t.Run("Cookie from another login", func(t *testing.T) {
+		cookie, _ := startLogin(t, login)
+		_, state := startLogin(t, login)
+		if code := callback(login, cookie, url.Values{"code": {"the-code"}, "state": {state}}); code != http.StatusBadRequest {
+			t.Errorf("Expected a state not matching the cookie to be rejected, got %d", code)
+		}
+	})
+
+	t.Run("Forged signature", func(t *testing.T) {
+		_, state := startLogin(t, login)
+		forged := state[:strings.Index(state, ".")] + ".AAAA"
+		cookie := &http.Cookie{Name: login.CookieName, Value: forged}
+		if code := callback(login, cookie, url.Values{"code": {"the-code"}, "state": {forged}}); code != http.StatusBadRequest {
+			t.Errorf("Expected a forged state to be rejected, got %d", code)
+		}
+	})
+
+	t.Run("Denied authorization", func(t *testing.T) {
+		cookie, state := startLogin(t, login)
+		if code := callback(login, cookie, url.Values{"error": {"access_denied"}, "state": {state}}); code != http.StatusBadRequest {
+			t.Errorf("Expected a denied authorization to be rejected, got %d", code)
+		}
+	})
+
+	t.Run("Missing code", func(t *testing.T) {
+		cookie, state := startLogin(t, login)
+		if code := callback(login, cookie, url.Values{"code": {""}, "state": {state}}); code != http.StatusBadRequest {
+			t.Errorf("Expected a callback without code to be rejected, got %d", code)
+		}
+	})
+
+	t.Run("Missing login hook", func(t *testing.T) {
+		login := newTestWebLogin(t, server.URL, &logins)
+		login.OnLogin = nil
+		cookie, state := startLogin(t, login)
+		if code := callback(login, cookie, url.Values{"code": {"the-code"}, "state": {state}}); code != http.StatusInternalServerError {
+			t.Errorf("Expected a WebLogin without OnLogin to fail, got %d", code)
+		}
+	})
+
+	if len(logins) != 0 {
+		t.Errorf("Expected no login, got %+v", logins)
+	}
+}
+
+func TestNewWebLogin_ShortKey(t *testing.T) {
+
+	auth := oauth.NewThreeLeggedClientV2("client", "secret", "http://localhost/callback")
+	onLogin := func(w http.ResponseWriter, r *http.Request, bearer oauth.Bearer, profile oauth.UserProfile) {}
+
+	for _, key := range [][]byte{nil, []byte("secret"), make([]byte, oauth.MinWebLoginKeySize-1)} {
+		if _, err := oauth.NewWebLogin(auth, "data:read", key, onLogin); err == nil {
+			t.Errorf("Expected a key of %d bytes to be rejected", len(key))
+		}
+	}
+
+	if _, err := oauth.NewWebLogin(auth, "data:read", make([]byte, oauth.MinWebLoginKeySize), onLogin); err != nil {
+		t.Errorf("Expected a key of %d bytes to be accepted, got %s", oauth.MinWebLoginKeySize, err.Error())
+	}
+}
@@ -2,33 +2,33 @@ package recap
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
 	"log"
+	"math/rand"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
-	"math/rand"
+
+	forge "github.com/outer-labs/forge-api-go-client"
 )
 
-func createPhotoScene(path string, name string, formats []string, sceneType string, token string) (scene PhotoScene, err error) {
+func createPhotoScene(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, name string, formats []string, sceneType string, token string) (scene PhotoScene, err error) {
 
 	if sceneType != "object" && sceneType != "aerial" {
 		err = errors.New("the scene type is not supported. Expecting 'object' or 'aerial', got " + sceneType)
 		return
 	}
-	task := http.Client{}
-
 	body := url.Values{}
 	body.Add("scenename", name)
 	body.Add("format", strings.Join(formats, ","))
 	body.Add("scenetype", sceneType)
 
-	req, err := http.NewRequest("POST",
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/photoscene",
 		bytes.NewBufferString(body.Encode()),
 	)
@@ -38,15 +38,14 @@ func createPhotoScene(path string, name string, formats []string, sceneType stri
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -62,7 +61,7 @@ func createPhotoScene(path string, name string, formats []string, sceneType stri
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := sceneCreationReply.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
@@ -71,9 +70,7 @@ func createPhotoScene(path string, name string, formats []string, sceneType stri
 	return
 }
 
-func addFileToSceneUsingLink(path string, photoSceneID string, link string, token string) (result FileUploadingReply, err error) {
-
-	task := http.Client{}
+func addFileToSceneUsingLink(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, link string, token string) (result FileUploadingReply, err error) {
 
 	//params := `photosceneid=` + photoSceneID + `&type=image`
 	//params += `&file[0]=` + link
@@ -86,7 +83,7 @@ func addFileToSceneUsingLink(path string, photoSceneID string, link string, toke
 	writer.WriteField("type", "image")
 	writer.WriteField("file[0", link)
 
-	req, err := http.NewRequest("POST",
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/file",
 		body,
 	)
@@ -97,7 +94,7 @@ func addFileToSceneUsingLink(path string, photoSceneID string, link string, toke
 
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		log.Println("could not send image links: ", err.Error())
 		return
@@ -105,8 +102,7 @@ func addFileToSceneUsingLink(path string, photoSceneID string, link string, toke
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -120,14 +116,14 @@ func addFileToSceneUsingLink(path string, photoSceneID string, link string, toke
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
 
-func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte, token string) (result FileUploadingReply, err error) {
+func addFileToSceneUsingFileData(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, data []byte, token string) (result FileUploadingReply, err error) {
 
 	rand.Seed(time.Now().UnixNano())
 
@@ -135,7 +131,7 @@ func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte,
 	writer := multipart.NewWriter(body)
 	writer.WriteField("photosceneid", photoSceneID)
 	writer.WriteField("type", "image")
-	formFile, err := writer.CreateFormFile("file[0]", "data" + strconv.Itoa(rand.Int()))
+	formFile, err := writer.CreateFormFile("file[0]", "data"+strconv.Itoa(rand.Int()))
 	if err != nil {
 		log.Println(err.Error())
 		return
@@ -143,9 +139,7 @@ func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte,
 	formFile.Write(data)
 	writer.Close()
 
-	task := http.Client{}
-
-	req, err := http.NewRequest("POST",
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/file",
 		body)
 
@@ -155,7 +149,7 @@ func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte,
 
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 
 	if err != nil {
 		return
@@ -163,8 +157,7 @@ func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte,
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -178,17 +171,15 @@ func addFileToSceneUsingFileData(path string, photoSceneID string, data []byte,
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
 
-func startSceneProcessing(path string, photoSceneID string, token string) (result SceneStartProcessingReply, err error) {
-	task := http.Client{}
-
-	req, err := http.NewRequest("POST",
+func startSceneProcessing(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, token string) (result SceneStartProcessingReply, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/photoscene/"+photoSceneID,
 		nil,
 	)
@@ -198,15 +189,14 @@ func startSceneProcessing(path string, photoSceneID string, token string) (resul
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -220,17 +210,15 @@ func startSceneProcessing(path string, photoSceneID string, token string) (resul
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
 
-func getSceneProgress(path string, photoSceneID string, token string) (result SceneProgressReply, err error) {
-	task := http.Client{}
-
-	req, err := http.NewRequest("GET",
+func getSceneProgress(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, token string) (result SceneProgressReply, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/photoscene/"+photoSceneID+"/progress",
 		nil,
 	)
@@ -240,15 +228,14 @@ func getSceneProgress(path string, photoSceneID string, token string) (result Sc
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -262,19 +249,17 @@ func getSceneProgress(path string, photoSceneID string, token string) (result Sc
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
 
-func getSceneResult(path string, photoSceneID string, token string, format string) (result SceneResultReply, err error) {
-	task := http.Client{}
-
+func getSceneResult(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, token string, format string) (result SceneResultReply, err error) {
 	body := strings.NewReader("format=" + format)
 
-	req, err := http.NewRequest("GET",
+	req, err := forge.NewRequest(ctx, limiter, "GET",
 		path+"/photoscene/"+photoSceneID,
 		body,
 	)
@@ -284,15 +269,14 @@ func getSceneResult(path string, photoSceneID string, token string, format strin
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -306,17 +290,15 @@ func getSceneResult(path string, photoSceneID string, token string, format strin
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
 
-func cancelSceneProcessing(path string, photoSceneID string, token string) (result SceneCancelReply, err error) {
-	task := http.Client{}
-
-	req, err := http.NewRequest("POST",
+func cancelSceneProcessing(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, token string) (result SceneCancelReply, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "POST",
 		path+"/photoscene/"+photoSceneID+"/cancel",
 		nil,
 	)
@@ -326,15 +308,14 @@ func cancelSceneProcessing(path string, photoSceneID string, token string) (resu
 	}
 
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -349,7 +330,7 @@ func cancelSceneProcessing(path string, photoSceneID string, token string) (resu
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
@@ -357,10 +338,8 @@ func cancelSceneProcessing(path string, photoSceneID string, token string) (resu
 
 }
 
-func deleteScene(path string, photoSceneID string, token string) (result SceneDeletionReply, err error) {
-	task := http.Client{}
-
-	req, err := http.NewRequest("DELETE",
+func deleteScene(ctx context.Context, client *forge.Client, limiter HttpRequestLimiter, path string, photoSceneID string, token string) (result SceneDeletionReply, err error) {
+	req, err := forge.NewRequest(ctx, limiter, "DELETE",
 		path+"/photoscene/"+photoSceneID,
 		nil,
 	)
@@ -370,15 +349,14 @@ func deleteScene(path string, photoSceneID string, token string) (result SceneDe
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
-	response, err := task.Do(req)
+	response, err := forge.Do(client, limiter, req)
 	if err != nil {
 		return
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
-		content, _ := ioutil.ReadAll(response.Body)
-		err = errors.New("[" + strconv.Itoa(response.StatusCode) + "] " + string(content))
+		err = forge.NewAPIError(response)
 		return
 	}
 
@@ -392,9 +370,20 @@ func deleteScene(path string, photoSceneID string, token string) (result SceneDe
 
 	// This check is necessary, as there are cases when server returns status OK, but contains an error message
 	if bodyError := result.Error; bodyError != nil {
-		err = errors.New("[" + bodyError.Code + "] " + bodyError.Message)
+		err = replyError(response, bodyError)
 		return
 	}
 
 	return
 }
+
+// replyError returns the error the Reality Capture API reports in the body of a response with status OK
+func replyError(response *http.Response, bodyError *Error) *forge.APIError {
+	err := forge.NewAPIError(response)
+	// the body was consumed when decoding the reply
+	err.Body = nil
+	err.Code = bodyError.Code
+	err.Message = bodyError.Message
+
+	return err
+}
@@ -1,6 +1,7 @@
 package recap_test
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -8,7 +9,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/apprentice3d/forge-api-go-client/recap"
+	"github.com/outer-labs/forge-api-go-client/dm"
+	"github.com/outer-labs/forge-api-go-client/recap"
 )
 
 func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
@@ -35,11 +37,13 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 		t.Skipf("No Forge credentials present; skipping test")
 	}
 
-	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret)
+	ctx := context.Background()
+
+	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
 
 	t.Run("Creating a new photoScene", func(t *testing.T) {
 		var err error
-		scene, err = recapAPI.CreatePhotoScene("example", []string{testingFormat}, "object")
+		scene, err = recapAPI.CreatePhotoScene(ctx, "example", []string{testingFormat}, "object")
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -47,7 +51,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 
 	t.Run("Uploading sample images using links", func(t *testing.T) {
 		for _, link := range linkSamples {
-			_, err := recapAPI.AddFileToSceneUsingLink(scene.ID, link)
+			_, err := recapAPI.AddFileToSceneUsingLink(ctx, scene.ID, link)
 			if err != nil {
 				t.Fatal(err.Error())
 			}
@@ -55,7 +59,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 	})
 
 	t.Run("Starting photoScene processing", func(t *testing.T) {
-		if _, err := recapAPI.StartSceneProcessing(scene.ID); err != nil {
+		if _, err := recapAPI.StartSceneProcessing(ctx, scene.ID); err != nil {
 			t.Error(err.Error())
 		}
 	})
@@ -64,7 +68,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 		var progressResult recap.SceneProgressReply
 		var err error
 		for {
-			if progressResult, err = recapAPI.GetSceneProgress(scene.ID); err != nil {
+			if progressResult, err = recapAPI.GetSceneProgress(ctx, scene.ID); err != nil {
 				t.Errorf("Failed to get the PhotoScene progress: %s\n", err.Error())
 			}
 
@@ -82,7 +86,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 	})
 
 	t.Run("Get the available result", func(t *testing.T) {
-		result, err := recapAPI.GetSceneResults(scene.ID, testingFormat)
+		result, err := recapAPI.GetSceneResults(ctx, scene.ID, testingFormat)
 		if err != nil {
 			t.Error(err.Error())
 		}
@@ -92,7 +96,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 	})
 
 	t.Run("Check the result file size for normal size", func(t *testing.T) {
-		response, err := recapAPI.GetSceneResults(scene.ID, testingFormat)
+		response, err := recapAPI.GetSceneResults(ctx, scene.ID, testingFormat)
 		if err != nil {
 			t.Error(err.Error())
 		}
@@ -130,7 +134,7 @@ func TestReCapAPIWorkflowUsingRemoteLinks(t *testing.T) {
 	})
 
 	t.Run("Delete the scene", func(t *testing.T) {
-		_, err := recapAPI.DeleteScene(scene.ID)
+		_, err := recapAPI.DeleteScene(ctx, scene.ID)
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -164,11 +168,13 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 		t.Skipf("No Forge credentials present; skipping test")
 	}
 
-	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret)
+	ctx := context.Background()
+
+	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
 
 	t.Run("Creating a new photoScene", func(t *testing.T) {
 		var err error
-		scene, err = recapAPI.CreatePhotoScene("example", []string{testingFormat}, "object")
+		scene, err = recapAPI.CreatePhotoScene(ctx, "example", []string{testingFormat}, "object")
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -190,7 +196,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 				t.Fatal(err.Error())
 			}
 
-			_, err = recapAPI.AddFileToSceneUsingData(scene.ID, data)
+			_, err = recapAPI.AddFileToSceneUsingData(ctx, scene.ID, data)
 			if err != nil {
 				t.Fatal(err.Error())
 			}
@@ -199,7 +205,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 	})
 
 	t.Run("Starting photoScene processing", func(t *testing.T) {
-		if _, err := recapAPI.StartSceneProcessing(scene.ID); err != nil {
+		if _, err := recapAPI.StartSceneProcessing(ctx, scene.ID); err != nil {
 			t.Error(err.Error())
 		}
 	})
@@ -208,7 +214,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 		var progressResult recap.SceneProgressReply
 		var err error
 		for {
-			if progressResult, err = recapAPI.GetSceneProgress(scene.ID); err != nil {
+			if progressResult, err = recapAPI.GetSceneProgress(ctx, scene.ID); err != nil {
 				t.Errorf("Failed to get the PhotoScene progress: %s\n", err.Error())
 			}
 
@@ -226,7 +232,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 	})
 
 	t.Run("Get the available result", func(t *testing.T) {
-		result, err := recapAPI.GetSceneResults(scene.ID, testingFormat)
+		result, err := recapAPI.GetSceneResults(ctx, scene.ID, testingFormat)
 		if err != nil {
 			t.Error(err.Error())
 		}
@@ -236,7 +242,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 	})
 
 	t.Run("Check the result file size for normal size", func(t *testing.T) {
-		response, err := recapAPI.GetSceneResults(scene.ID, testingFormat)
+		response, err := recapAPI.GetSceneResults(ctx, scene.ID, testingFormat)
 		if err != nil {
 			t.Error(err.Error())
 		}
@@ -274,7 +280,7 @@ func TestReCapAPIWorkflowUsingLocalFiles(t *testing.T) {
 	})
 
 	t.Run("Delete the scene", func(t *testing.T) {
-		_, err := recapAPI.DeleteScene(scene.ID)
+		_, err := recapAPI.DeleteScene(ctx, scene.ID)
 		if err != nil {
 			t.Fatal(err.Error())
 		}
@@ -291,11 +297,13 @@ func TestCreatePhotoScene(t *testing.T) {
 	if clientID == "" || clientSecret == "" {
 		t.Skipf("No Forge credentials present; skipping test")
 	}
-	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret)
+
+	ctx := context.Background()
+	recapAPI := recap.NewAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
 	var sceneID string
 
 	t.Run("Create a scene", func(t *testing.T) {
-		response, err := recapAPI.CreatePhotoScene("testare", nil, "object")
+		response, err := recapAPI.CreatePhotoScene(ctx, "testare", nil, "object")
 
 		if err != nil {
 			t.Fatalf("Failed to create a photoscene: %s\n", err.Error())
@@ -305,7 +313,7 @@ func TestCreatePhotoScene(t *testing.T) {
 	})
 
 	t.Run("Delete the test scene", func(t *testing.T) {
-		_, err := recapAPI.DeleteScene(sceneID)
+		_, err := recapAPI.DeleteScene(ctx, sceneID)
 
 		if err != nil {
 			t.Fatalf("Failed to delete the photoscene: %s\n", err.Error())
@@ -313,7 +321,7 @@ func TestCreatePhotoScene(t *testing.T) {
 	})
 
 	t.Run("Check fail on create a scene with empty name", func(t *testing.T) {
-		_, err := recapAPI.CreatePhotoScene("", nil, "object")
+		_, err := recapAPI.CreatePhotoScene(ctx, "", nil, "object")
 
 		if err == nil {
 			t.Fatalf("Should fail creating a scene with empty name\n")
@@ -326,9 +334,9 @@ func ExampleAPI_CreatePhotoScene() {
 
 	clientID := os.Getenv("FORGE_CLIENT_ID")
 	clientSecret := os.Getenv("FORGE_CLIENT_SECRET")
-	recap := recap.NewAPIWithCredentials(clientID, clientSecret)
+	recap := recap.NewAPIWithCredentials(clientID, clientSecret, dm.DefaultRateLimiter)
 
-	photoScene, err := recap.CreatePhotoScene("test_scene", nil, "object")
+	photoScene, err := recap.CreatePhotoScene(context.Background(), "test_scene", nil, "object")
 	if err != nil {
 		// handle error
 	}
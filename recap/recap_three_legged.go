@@ -0,0 +1,110 @@
+package recap
+
+import (
+	"context"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+)
+
+// API3L struct holds all paths necessary to access ReCap API in 3-legged context
+type API3L struct {
+	Auth        oauth.ThreeLeggedAuth
+	Token       TokenRefresher
+	ReCapPath   string
+	RateLimiter HttpRequestLimiter
+}
+
+// NewAPI3LWithCredentials returns a ReCap API client acting on behalf of the user who granted the token.
+// The requests are not rate limited when limiter is nil.
+func NewAPI3LWithCredentials(auth oauth.ThreeLeggedAuth, token TokenRefresher, limiter HttpRequestLimiter) *API3L {
+	return &API3L{
+		Auth:        auth,
+		Token:       token,
+		ReCapPath:   "/photo-to-3d/v1",
+		RateLimiter: limiter,
+	}
+}
+
+// CreatePhotoScene3L prepares a scene, see API.CreatePhotoScene
+func (api API3L) CreatePhotoScene3L(ctx context.Context, name string, formats []string, sceneType string) (scene PhotoScene, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return createPhotoScene(ctx, api.Auth.Client, api.RateLimiter, path, name, formats, sceneType, api.Token.Bearer().AccessToken)
+}
+
+// AddFileToSceneUsingLink3L adds a remotely available image to the scene
+func (api API3L) AddFileToSceneUsingLink3L(ctx context.Context, sceneID string, link string) (uploads FileUploadingReply, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return addFileToSceneUsingLink(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, link, api.Token.Bearer().AccessToken)
+}
+
+// AddFileToSceneUsingData3L uploads an image available as a byte slice to the scene
+func (api API3L) AddFileToSceneUsingData3L(ctx context.Context, sceneID string, data []byte) (uploads FileUploadingReply, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return addFileToSceneUsingFileData(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, data, api.Token.Bearer().AccessToken)
+}
+
+// StartSceneProcessing3L triggers the processing of the scene
+func (api API3L) StartSceneProcessing3L(ctx context.Context, sceneID string) (result SceneStartProcessingReply, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return startSceneProcessing(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, api.Token.Bearer().AccessToken)
+}
+
+// GetSceneProgress3L polls the scene processing status and progress
+func (api API3L) GetSceneProgress3L(ctx context.Context, sceneID string) (progress SceneProgressReply, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return getSceneProgress(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, api.Token.Bearer().AccessToken)
+}
+
+// GetSceneResults3L requests result in a specified format
+func (api API3L) GetSceneResults3L(ctx context.Context, sceneID string, format string) (result SceneResultReply, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	return getSceneResult(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, api.Token.Bearer().AccessToken, format)
+}
+
+// CancelSceneProcessing3L stops the scene processing, without affecting the already uploaded resources
+func (api API3L) CancelSceneProcessing3L(ctx context.Context, sceneID string) (ID string, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	_, err = cancelSceneProcessing(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, api.Token.Bearer().AccessToken)
+
+	return sceneID, err
+}
+
+// DeleteScene3L removes all the resources associated with given scene
+func (api API3L) DeleteScene3L(ctx context.Context, sceneID string) (ID string, err error) {
+	if err = api.Token.RefreshTokenIfRequired(api.Auth); err != nil {
+		return
+	}
+
+	path := api.Auth.Host + api.ReCapPath
+	_, err = deleteScene(ctx, api.Auth.Client, api.RateLimiter, path, sceneID, api.Token.Bearer().AccessToken)
+
+	return sceneID, err
+}
@@ -0,0 +1,86 @@
+package recap_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/outer-labs/forge-api-go-client/oauth"
+	"github.com/outer-labs/forge-api-go-client/recap"
+)
+
+// countingLimiter records the requests made through it
+type countingLimiter struct {
+	requests int
+}
+
+func (l *countingLimiter) HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	l.requests++
+	return http.NewRequest(method, url, body)
+}
+
+func TestAPI3L_GetSceneProgress3L(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/photo-to-3d/v1/photoscene/scene-id/progress" || r.Header.Get("Authorization") != "Bearer access" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"photoscene": map[string]string{"photosceneid": "scene-id", "progressmsg": "DONE", "progress": "100"},
+		})
+	}))
+	defer server.Close()
+
+	auth := oauth.NewThreeLeggedClient("client", "secret", "http://localhost/callback")
+	auth.Host = server.URL
+	token := oauth.NewRefreshableTokenWithScope(&oauth.Bearer{AccessToken: "access"}, time.Now().Add(time.Hour), oauth.NewScopes(oauth.ScopeDataRead))
+	limiter := &countingLimiter{}
+
+	api := recap.NewAPI3LWithCredentials(auth, token, limiter)
+
+	progress, err := api.GetSceneProgress3L(context.Background(), "scene-id")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if progress.PhotoScene.Progress != "100" {
+		t.Errorf("Unexpected progress: %+v", progress)
+	}
+	if limiter.requests != 1 {
+		t.Errorf("Expected the request to go through the limiter, got %d", limiter.requests)
+	}
+
+	t.Run("Scope checked by the server", func(t *testing.T) {
+		requests := limiter.requests
+		_, err := api.DeleteScene3L(context.Background(), "scene-id")
+		var scopeErr *oauth.ScopeError
+		if errors.As(err, &scopeErr) {
+			t.Errorf("Expected the request to be sent whatever the granted scope, got %s", err.Error())
+		}
+		if limiter.requests != requests+1 {
+			t.Error("Expected the request to be sent to the server")
+		}
+	})
+
+	t.Run("Cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := api.GetSceneProgress3L(ctx, "scene-id"); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the cancellation to be reported, got %v", err)
+		}
+	})
+	t.Run("Without limiter", func(t *testing.T) {
+		api := recap.NewAPI3LWithCredentials(auth, token, nil)
+		if _, err := api.GetSceneProgress3L(context.Background(), "scene-id"); err != nil {
+			t.Errorf("Expected the request to be sent without rate limiting, got %v", err)
+		}
+	})
+}
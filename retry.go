@@ -0,0 +1,274 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how the Retry middleware sends again the requests failing with a transient error:
+// a network error, a 429 Too Many Requests or a 500, 502, 503 or 504 status.
+//
+// The waits between attempts grow exponentially from InitialInterval up to MaxInterval, each one randomized
+// by Jitter. A Retry-After header sent by the server replaces the computed wait.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including the first one.
+	// Values below 2 disable the retries.
+	MaxAttempts int
+	// InitialInterval is the wait before the first retry
+	InitialInterval time.Duration
+	// MaxInterval caps the wait between two attempts, unless the server asks for a longer one with Retry-After
+	MaxInterval time.Duration
+	// Multiplier is applied to the wait after every attempt
+	Multiplier float64
+	// Jitter randomizes every wait by up to ±Jitter of its value, so that clients failing together
+	// do not retry together
+	Jitter float64
+	// MaxElapsedTime, if set, stops the retries when the next attempt would start after this duration
+	// from the first one. The last failed response or error is then returned.
+	MaxElapsedTime time.Duration
+	// Retryable reports whether a request may be sent again, DefaultRetryable if nil
+	Retryable func(req *http.Request) bool
+}
+
+// DefaultRetryPolicy makes up to 5 attempts over at most 2 minutes
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     30 * time.Second,
+	Multiplier:      2,
+	Jitter:          0.5,
+	MaxElapsedTime:  2 * time.Minute,
+}
+
+// NoRetry is a RetryPolicy sending every request only once
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryable allows retrying the idempotent requests, GET, HEAD, OPTIONS and DELETE,
+// and the chunks of resumable uploads, which can be sent again as they carry their byte range
+func DefaultRetryable(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD", "OPTIONS", "DELETE":
+		return true
+	case "PUT":
+		return len(req.Header.Get("Session-Id")) != 0 && len(req.Header.Get("Content-Range")) != 0
+	}
+
+	return false
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context making the Client use policy, instead of its own,
+// for the requests made with it. Use NoRetry to disable the retries of a call.
+func WithRetryPolicy(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+type retryStateKey struct{}
+
+// retryState is shared by the Retry layers a request goes through, so that only the innermost one retries it
+type retryState struct {
+	handled bool
+}
+
+// Retry returns a middleware sending again the requests failing with a transient error, according to policy
+// or to the policy set in the context of the request with WithRetryPolicy.
+//
+// Every Client retries with DefaultRetryPolicy before its middlewares. Adding Retry to the chain replaces it,
+// e.g. NewClient(WithMiddleware(Retry(NoRetry))) sends every request once: when several Retry layers are chained,
+// only the innermost one retries.
+//
+// Requests with a body are only retried if the body can be rewound, which is the case of the requests
+// created by http.NewRequest with a *bytes.Buffer, *bytes.Reader or *strings.Reader body.
+// Every new attempt waits for the limiter the request was created with by NewRequest. To have it also wait
+// for the RateLimit middleware, add Retry before it.
+func Retry(policy RetryPolicy) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if outer, ok := req.Context().Value(retryStateKey{}).(*retryState); ok {
+				outer.handled = true
+			}
+			state := &retryState{}
+			req = req.WithContext(context.WithValue(req.Context(), retryStateKey{}, state))
+
+			p := policy
+			if override, ok := req.Context().Value(retryPolicyKey{}).(RetryPolicy); ok {
+				p = override
+			}
+
+			return p.roundTrip(next, req, state)
+		})
+	}
+}
+
+func (p RetryPolicy) roundTrip(next http.RoundTripper, req *http.Request, state *retryState) (*http.Response, error) {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	if p.MaxAttempts < 2 || !retryable(req) || !rewindable(req) {
+		return next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	start := time.Now()
+	interval := p.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		if attempt > 1 {
+			if err := waitLimiter(attemptReq); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := next.RoundTrip(attemptReq)
+		if state.handled || attempt >= p.MaxAttempts || !isTransient(ctx, response, err) {
+			return response, err
+		}
+
+		wait := p.jitter(interval)
+		if after, ok := RetryAfter(response); ok {
+			wait = after
+		}
+		if p.MaxElapsedTime > 0 && time.Since(start)+wait > p.MaxElapsedTime {
+			return response, err
+		}
+
+		if response != nil {
+			if observer, ok := ctx.Value(requestLimiterKey{}).(ResponseObserver); ok {
+				observer.ObserveResponse(attemptReq, response)
+			}
+			// drain the body so that the connection can be reused
+			io.CopyN(ioutil.Discard, response.Body, 4096)
+			response.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		interval = p.next(interval)
+	}
+}
+
+// waitLimiter waits for the limiter req was created with by NewRequest, if any
+func waitLimiter(req *http.Request) error {
+	switch limiter := req.Context().Value(requestLimiterKey{}).(type) {
+	case Limiter:
+		if err := limiter.Wait(req.Context(), req.Method, req.URL.String()); err != nil {
+			return fmt.Errorf("rate limit wait: %w", err)
+		}
+	case RequestLimiter:
+		// the request is only created to wait for the limits
+		if _, err := limiter.HttpRequest(req.Context(), req.Method, req.URL.String(), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// next returns the wait following interval
+func (p RetryPolicy) next(interval time.Duration) time.Duration {
+	if p.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * p.Multiplier)
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+
+	return interval
+}
+
+// jitter randomizes interval by up to ±Jitter of its value
+func (p RetryPolicy) jitter(interval time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return interval
+	}
+	delta := p.Jitter * float64(interval)
+
+	return time.Duration(float64(interval) - delta + rand.Float64()*2*delta)
+}
+
+// isTransient reports whether the failure of a request may not happen again
+func isTransient(ctx context.Context, response *http.Response, err error) bool {
+	if err != nil {
+		// a limiter refusing to wait past the deadline returns a context error before ctx is done
+		return ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// RetryAfter returns the wait asked by the Retry-After header of response, given in seconds or as a date
+func RetryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	value := response.Header.Get("Retry-After")
+	if len(value) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// rewindable reports whether the body of req can be sent again
+func rewindable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewind returns a copy of req with a new body
+func rewind(req *http.Request) (*http.Request, error) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry.Body = body
+
+	return retry, nil
+}
@@ -0,0 +1,229 @@
+package forge_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	forge "github.com/outer-labs/forge-api-go-client"
+)
+
+// fastRetries retries quickly, so that the tests do not wait
+var fastRetries = forge.RetryPolicy{
+	MaxAttempts:     3,
+	InitialInterval: time.Millisecond,
+	MaxInterval:     time.Millisecond,
+	Multiplier:      2,
+}
+
+// flakyServer fails the first failures requests with status, then responds 200
+func flakyServer(failures int32, status int, attempts *int32, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if bodies != nil {
+			*bodies = append(*bodies, string(body))
+		}
+		if atomic.AddInt32(attempts, 1) <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestRetry(t *testing.T) {
+
+	t.Run("Transient failures", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(2, http.StatusServiceUnavailable, &attempts, nil)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries)))
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusOK || attempts != 3 {
+			t.Errorf("Expected success after 3 attempts, got %d after %d", response.StatusCode, attempts)
+		}
+	})
+
+	t.Run("Too many failures", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(5, http.StatusTooManyRequests, &attempts, nil)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries)))
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusTooManyRequests || attempts != 3 {
+			t.Errorf("Expected the last failure after 3 attempts, got %d after %d", response.StatusCode, attempts)
+		}
+	})
+
+	t.Run("Non idempotent request", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(1, http.StatusServiceUnavailable, &attempts, nil)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries)))
+		req, _ := http.NewRequest("POST", server.URL, bytes.NewBufferString("job"))
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if attempts != 1 {
+			t.Errorf("Expected a POST not to be retried, got %d attempts", attempts)
+		}
+	})
+
+	t.Run("Resumable chunk", func(t *testing.T) {
+		var attempts int32
+		var bodies []string
+		server := flakyServer(1, http.StatusBadGateway, &attempts, &bodies)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries)))
+		req, _ := http.NewRequest("PUT", server.URL, bytes.NewBufferString("chunk"))
+		req.Header.Set("Session-Id", "session")
+		req.Header.Set("Content-Range", "bytes 0-4/10")
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if len(bodies) != 2 || bodies[0] != "chunk" || bodies[1] != "chunk" {
+			t.Errorf("Expected the chunk to be sent again with its body, got %q", bodies)
+		}
+	})
+
+	t.Run("Per-call override", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(1, http.StatusServiceUnavailable, &attempts, nil)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries)))
+		req, _ := http.NewRequestWithContext(forge.WithRetryPolicy(context.Background(), forge.NoRetry), "GET", server.URL, nil)
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+			t.Errorf("Expected the retries to be disabled for the call, got %d after %d attempts", response.StatusCode, attempts)
+		}
+	})
+
+	t.Run("Default policy", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(1, http.StatusServiceUnavailable, &attempts, nil)
+		defer server.Close()
+
+		var client *forge.Client
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusOK || attempts != 2 {
+			t.Errorf("Expected a Client to retry by default, got %d after %d attempts", response.StatusCode, attempts)
+		}
+	})
+
+	t.Run("Retries disabled", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(1, http.StatusServiceUnavailable, &attempts, nil)
+		defer server.Close()
+
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(forge.NoRetry)))
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if response.StatusCode != http.StatusServiceUnavailable || attempts != 1 {
+			t.Errorf("Expected the Retry middleware to replace the default policy, got %d after %d attempts", response.StatusCode, attempts)
+		}
+	})
+
+	t.Run("Rate limited attempts", func(t *testing.T) {
+		var attempts int32
+		server := flakyServer(2, http.StatusTooManyRequests, &attempts, nil)
+		defer server.Close()
+
+		limiter := &countingRequestLimiter{}
+		req, err := forge.NewRequest(context.Background(), limiter, "GET", server.URL, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response, err := forge.NewClient(forge.WithMiddleware(forge.Retry(fastRetries))).Do(req)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if limiter.requests != 3 {
+			t.Errorf("Expected every attempt to wait for the limiter, got %d waits for %d attempts", limiter.requests, attempts)
+		}
+		if len(limiter.statuses) != 2 || limiter.statuses[0] != http.StatusTooManyRequests {
+			t.Errorf("Expected the retried responses to be reported to the limiter, got %v", limiter.statuses)
+		}
+	})
+
+	t.Run("Retry-After beyond the elapsed time", func(t *testing.T) {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		policy := fastRetries
+		policy.MaxElapsedTime = time.Minute
+		client := forge.NewClient(forge.WithMiddleware(forge.Retry(policy)))
+		response, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		response.Body.Close()
+
+		if attempts != 1 {
+			t.Errorf("Expected no retry when the server asks to wait longer than MaxElapsedTime, got %d attempts", attempts)
+		}
+	})
+}
+
+// countingRequestLimiter counts the requests created through it and records the responses reported to it
+type countingRequestLimiter struct {
+	requests int
+	statuses []int
+}
+
+func (l *countingRequestLimiter) HttpRequest(ctx context.Context, method string, url string, body io.Reader) (*http.Request, error) {
+	l.requests++
+	return http.NewRequestWithContext(ctx, method, url, body)
+}
+
+func (l *countingRequestLimiter) ObserveResponse(req *http.Request, response *http.Response) {
+	l.statuses = append(l.statuses, response.StatusCode)
+}